@@ -0,0 +1,346 @@
+package i18n4v
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Kinds of problems that Compare reports.
+const (
+	MissingKey            = "missing-key"
+	ExtraKey              = "extra-key"
+	PlaceholderMismatch   = "placeholder-mismatch"
+	PluralisationMismatch = "pluralisation-mismatch"
+	MissingContext        = "missing-context"
+	ExtraContext          = "extra-context"
+	StaleTranslation      = "stale-translation"
+)
+
+type nodeContext struct {
+	name   string
+	offset int
+	values *jsonNode
+}
+
+type nodeDictionary struct {
+	values     *jsonNode
+	meta       *jsonNode
+	contexts   map[string]*nodeContext
+	order      []string
+	namespaces map[string]*nodeDictionary
+	// namespaceOrder keeps names of namespaces in written order.
+	namespaceOrder []string
+}
+
+func contextID(matches *jsonNode) string {
+	var conditions []string
+	for _, match := range matches.members() {
+		conditions = append(conditions, fmt.Sprintf("%s=%v", match.key, match.value.value))
+	}
+	sort.Strings(conditions)
+	return "{" + strings.Join(conditions, ", ") + "}"
+}
+
+func newNodeDictionary(root *jsonNode, prefix string) *nodeDictionary {
+	result := &nodeDictionary{
+		contexts:   make(map[string]*nodeContext),
+		namespaces: make(map[string]*nodeDictionary),
+	}
+	if values := root.member("values"); values != nil {
+		result.values = values.value
+	}
+	if meta := root.member("meta"); meta != nil {
+		result.meta = meta.value
+	}
+	if contexts := root.member("contexts"); contexts != nil {
+		for _, contextNode := range contexts.value.array() {
+			matches := contextNode.member("matches")
+			if matches == nil {
+				continue
+			}
+			id := contextID(matches.value)
+			context, ok := result.contexts[id]
+			if !ok {
+				context = &nodeContext{
+					name:   prefix + "context" + id,
+					offset: contextNode.offset,
+				}
+				result.contexts[id] = context
+				result.order = append(result.order, id)
+			}
+			if values := contextNode.member("values"); values != nil {
+				context.values = values.value
+			}
+		}
+	}
+	if prefix != "" {
+		return result
+	}
+	if namespaces := root.member("namespaces"); namespaces != nil {
+		for _, namespace := range namespaces.value.members() {
+			if _, ok := result.namespaces[namespace.key]; !ok {
+				result.namespaceOrder = append(result.namespaceOrder, namespace.key)
+			}
+			result.namespaces[namespace.key] = newNodeDictionary(namespace.value, namespacePrefix(namespace.key))
+		}
+	}
+	return result
+}
+
+// sourceTexts returns texts of the key in root values and then contexts in written order.
+// Pluralisation and select form return all texts, and empty text is replaced with key.
+func (d *nodeDictionary) sourceTexts(key string) []string {
+	var result []string
+	add := func(values *jsonNode) {
+		if values == nil {
+			return
+		}
+		member := values.member(key)
+		if member == nil {
+			return
+		}
+		result = append(result, nodeTexts(member.value)...)
+	}
+	add(d.values)
+	for _, id := range d.order {
+		add(d.contexts[id].values)
+	}
+	for i, text := range result {
+		if text == "" {
+			result[i] = key
+		}
+	}
+	return result
+}
+
+/*
+SourceHash returns hash of source texts of a key in reference dictionary.
+
+Texts are translations of the key in root values and then contexts in written order
+(all texts of pluralisation, texts of select cases in the order of case names, and key instead of empty text).
+Translation tools store it in "source" of meta section of target dictionaries,
+and Compare reports translations whose hash doesn't match reference as stale.
+*/
+func SourceHash(texts []string) string {
+	hash := sha256.Sum256([]byte(strings.Join(texts, "\x00")))
+	return hex.EncodeToString(hash[:8])
+}
+
+func namespacePrefix(name string) string {
+	return fmt.Sprintf("namespace[%s] ", name)
+}
+
+type comparator struct {
+	target   *jsonSource
+	problems []*Problem
+}
+
+func (c *comparator) report(offset int, kind, context, key, format string, args ...interface{}) {
+	problem := &Problem{
+		Kind:    kind,
+		Context: context,
+		Key:     key,
+		Message: fmt.Sprintf(format, args...),
+	}
+	if offset >= 0 {
+		problem.Line, problem.Column = c.target.position(offset)
+	}
+	c.problems = append(c.problems, problem)
+}
+
+/*
+Compare reads reference dictionary (like en.json) and target dictionary (like ja.json)
+and reports differences between them.
+
+It reports keys and contexts that are missing in target or that exist only in target,
+placeholder (%{key}) sets that differ, and entries that are pluralisation in one dictionary but
+plain string in another one. Positions in problems point to target dictionary.
+Translations whose source hash in meta section (see SourceHash) doesn't match reference are reported as stale.
+Namespaces are compared with the namespace of the same name.
+
+If JSON format is invalid, it returns error.
+*/
+func Compare(reference, target io.Reader) ([]*Problem, error) {
+	_, referenceRoot, err := parseJSONNode(reference)
+	if err != nil {
+		return nil, err
+	}
+	targetSrc, targetRoot, err := parseJSONNode(target)
+	if err != nil {
+		return nil, err
+	}
+	c := &comparator{target: targetSrc}
+	referenceDict := newNodeDictionary(referenceRoot, "")
+	targetDict := newNodeDictionary(targetRoot, "")
+	c.compareDictionary("", referenceDict, targetDict)
+	// namespace that doesn't exist in one side is compared with empty dictionary
+	empty := &nodeDictionary{}
+	for _, name := range referenceDict.namespaceOrder {
+		targetNamespace, ok := targetDict.namespaces[name]
+		if !ok {
+			targetNamespace = empty
+		}
+		c.compareDictionary(namespacePrefix(name), referenceDict.namespaces[name], targetNamespace)
+	}
+	for _, name := range targetDict.namespaceOrder {
+		if _, ok := referenceDict.namespaces[name]; !ok {
+			c.compareDictionary(namespacePrefix(name), empty, targetDict.namespaces[name])
+		}
+	}
+	return c.problems, nil
+}
+
+func (c *comparator) compareDictionary(prefix string, referenceDict, targetDict *nodeDictionary) {
+	c.compareValues(prefix+"root values", referenceDict.values, targetDict.values)
+	for _, id := range referenceDict.order {
+		referenceContext := referenceDict.contexts[id]
+		targetContext, ok := targetDict.contexts[id]
+		if !ok {
+			c.report(-1, MissingContext, referenceContext.name, "", "%s is missing", referenceContext.name)
+			continue
+		}
+		c.compareValues(targetContext.name, referenceContext.values, targetContext.values)
+	}
+	for _, id := range targetDict.order {
+		if _, ok := referenceDict.contexts[id]; !ok {
+			targetContext := targetDict.contexts[id]
+			c.report(targetContext.offset, ExtraContext, targetContext.name, "", "%s doesn't exist in reference", targetContext.name)
+		}
+	}
+	c.compareSourceHashes(prefix+"meta", referenceDict, targetDict)
+}
+
+// compareSourceHashes reports translations that are made for old source texts.
+// Keys without source hash and keys that don't exist in reference are skipped.
+func (c *comparator) compareSourceHashes(context string, referenceDict, targetDict *nodeDictionary) {
+	if targetDict.meta == nil {
+		return
+	}
+	for _, member := range targetDict.meta.members() {
+		source := member.value.member("source")
+		if source == nil {
+			continue
+		}
+		hash, ok := source.value.value.(string)
+		if !ok {
+			continue
+		}
+		texts := referenceDict.sourceTexts(member.key)
+		if len(texts) == 0 {
+			continue
+		}
+		if SourceHash(texts) != hash {
+			c.report(source.value.offset, StaleTranslation, context, member.key, "source text of key '%s' is changed after translation. Review it and update source hash", member.key)
+		}
+	}
+}
+
+func (c *comparator) compareValues(context string, reference, target *jsonNode) {
+	targetMembers := make(map[string]*jsonMember)
+	if target != nil {
+		for _, member := range target.members() {
+			targetMembers[member.key] = member
+		}
+	}
+	referenceMembers := make(map[string]*jsonMember)
+	if reference != nil {
+		for _, member := range reference.members() {
+			referenceMembers[member.key] = member
+			targetMember, ok := targetMembers[member.key]
+			if !ok {
+				c.report(-1, MissingKey, context, member.key, "key '%s' at %s is missing", member.key, context)
+				continue
+			}
+			c.compareValue(context, member.key, member.value, targetMember.value)
+		}
+	}
+	if target != nil {
+		for _, member := range target.members() {
+			if _, ok := referenceMembers[member.key]; !ok {
+				c.report(member.keyOffset, ExtraKey, context, member.key, "key '%s' at %s doesn't exist in reference", member.key, context)
+			}
+		}
+	}
+}
+
+func (c *comparator) compareValue(context, key string, reference, target *jsonNode) {
+	// select forms (like gender) depend on the language, so they are compared only by placeholders.
+	selectForm := reference.isObj || target.isObj
+	if !selectForm && reference.isArray && !target.isArray {
+		c.report(target.offset, PluralisationMismatch, context, key, "key '%s' at %s is pluralisation in reference, but not pluralisation", key, context)
+		return
+	} else if !selectForm && !reference.isArray && target.isArray {
+		c.report(target.offset, PluralisationMismatch, context, key, "key '%s' at %s is not pluralisation in reference, but pluralisation", key, context)
+		return
+	}
+	referencePlaceholders := nodePlaceholders(key, reference)
+	targetPlaceholders := nodePlaceholders(key, target)
+	var missing []string
+	var extra []string
+	for name := range referencePlaceholders {
+		if !targetPlaceholders[name] {
+			missing = append(missing, "%{"+name+"}")
+		}
+	}
+	for name := range targetPlaceholders {
+		if !referencePlaceholders[name] {
+			extra = append(extra, "%{"+name+"}")
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	if len(missing) > 0 {
+		c.report(target.offset, PlaceholderMismatch, context, key, "key '%s' at %s doesn't have placeholders: %s", key, context, strings.Join(missing, ", "))
+	}
+	if len(extra) > 0 {
+		c.report(target.offset, PlaceholderMismatch, context, key, "key '%s' at %s has placeholders that don't exist in reference: %s", key, context, strings.Join(extra, ", "))
+	}
+}
+
+// nodePlaceholders returns all placeholders in translation including pluralisation entries and select cases.
+// Empty translation is treated as same as key.
+func nodePlaceholders(key string, node *jsonNode) map[string]bool {
+	result := make(map[string]bool)
+	for _, text := range nodeTexts(node) {
+		if text == "" {
+			text = key
+		}
+		for _, name := range placeholders(text) {
+			result[name] = true
+		}
+	}
+	return result
+}
+
+// nodeTexts returns texts of a translation: the text itself, texts of pluralisation entries or
+// texts of all select cases in the order of case names.
+func nodeTexts(node *jsonNode) []string {
+	if text, ok := node.value.(string); ok {
+		return []string{text}
+	}
+	var result []string
+	if node.isObj {
+		if cases := node.member("cases"); cases != nil {
+			members := append([]*jsonMember{}, cases.value.members()...)
+			sort.SliceStable(members, func(i, j int) bool {
+				return members[i].key < members[j].key
+			})
+			for _, member := range members {
+				result = append(result, nodeTexts(member.value)...)
+			}
+		}
+		return result
+	}
+	for _, pluralisation := range node.array() {
+		if spec := pluralisation.array(); len(spec) == 3 {
+			if text, ok := spec[2].value.(string); ok {
+				result = append(result, text)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,183 @@
+package i18n4v
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	en := `{
+    "values": {
+        "Cancel": "Cancel",
+        "Welcome %{name}": "Welcome %{name}",
+        "%n comments": [
+            [0, 0, "%n comments"],
+            [1, 1, "%n comment"],
+            [2, null, "%n comments"]
+        ],
+        "Save": "Save"
+    },
+    "contexts": [
+        {
+            "matches": {"gender": "male"},
+            "values": {
+                "Hello": "Hello sir"
+            }
+        }
+    ]
+}`
+	ja := `{
+    "values": {
+        "Cancel": "キャンセル",
+        "Welcome %{name}": "ようこそ",
+        "%n comments": "%n コメント",
+        "Deleted feature": "削除された機能"
+    },
+    "contexts": [
+        {
+            "matches": {"gender": "female"},
+            "values": {
+                "Hello": "こんにちは"
+            }
+        }
+    ]
+}`
+	problems, err := Compare(strings.NewReader(en), strings.NewReader(ja))
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	expected := []struct {
+		kind string
+		key  string
+		line int
+	}{
+		{PlaceholderMismatch, "Welcome %{name}", 4},
+		{PluralisationMismatch, "%n comments", 5},
+		{MissingKey, "Save", 0},
+		{ExtraKey, "Deleted feature", 6},
+		{MissingContext, "", 0},
+		{ExtraContext, "", 9},
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("It should find %d problems, but %d: %v", len(expected), len(problems), problems)
+	}
+	for i, e := range expected {
+		if problems[i].Kind != e.kind || problems[i].Key != e.key || problems[i].Line != e.line {
+			t.Errorf("problem[%d] should be %s of '%s' at line %d, but %s of '%s' at line %d: %s", i,
+				e.kind, e.key, e.line, problems[i].Kind, problems[i].Key, problems[i].Line, problems[i].Message)
+		}
+	}
+}
+
+func TestCompareNamespaces(t *testing.T) {
+	en := `{
+    "values": {"Save": "Save"},
+    "namespaces": {
+        "billing": {"values": {"Save": "Pay", "Invoice": "Invoice"}},
+        "admin": {"values": {"Users": "Users"}}
+    }
+}`
+	ja := `{
+    "values": {"Save": "保存"},
+    "namespaces": {
+        "billing": {"values": {"Save": "支払う"}}
+    }
+}`
+	problems, err := Compare(strings.NewReader(en), strings.NewReader(ja))
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	if len(problems) != 2 {
+		t.Fatalf("It should report 2 problems, but %v", problems)
+	}
+	if problems[0].Kind != MissingKey || problems[0].Key != "Invoice" || problems[0].Context != "namespace[billing] root values" {
+		t.Errorf("It should report missing key in namespace, but %v", problems[0])
+	}
+	if problems[1].Kind != MissingKey || problems[1].Key != "Users" || problems[1].Context != "namespace[admin] root values" {
+		t.Errorf("It should report keys of missing namespace, but %v", problems[1])
+	}
+}
+
+func TestCompareSourceHash(t *testing.T) {
+	en := `{
+    "values": {
+        "greeting": "Hello!",
+        "%n files": [[1, 1, "One file"], [2, null, "%n files"]],
+        "Save": ""
+    },
+    "namespaces": {
+        "billing": {"values": {"greeting": "Welcome, customer"}}
+    }
+}`
+	ja := fmt.Sprintf(`{
+    "values": {
+        "greeting": "こんにちは！",
+        "%%n files": [[null, null, "%%n ファイル"]],
+        "Save": "保存"
+    },
+    "meta": {
+        "greeting": {"source": "%s"},
+        "%%n files": {"source": "%s"},
+        "Save": {"source": "%s"}
+    },
+    "namespaces": {
+        "billing": {
+            "values": {"greeting": "ようこそ"},
+            "meta": {"greeting": {"source": "%s"}}
+        }
+    }
+}`, SourceHash([]string{"Hello"}), SourceHash([]string{"One file", "%n files"}), SourceHash([]string{"Save"}), SourceHash([]string{"Welcome"}))
+	problems, err := Compare(strings.NewReader(en), strings.NewReader(ja))
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	if len(problems) != 2 {
+		t.Fatalf("It should report 2 problems, but %v", problems)
+	}
+	if problems[0].Kind != StaleTranslation || problems[0].Key != "greeting" || problems[0].Context != "meta" || problems[0].Line != 8 {
+		t.Errorf("It should report stale translation, but %v", problems[0])
+	}
+	if problems[1].Kind != StaleTranslation || problems[1].Context != "namespace[billing] meta" {
+		t.Errorf("It should report stale translation in namespace, but %v", problems[1])
+	}
+}
+
+func TestCompareSelect(t *testing.T) {
+	en := `{
+    "values": {
+        "%{name} updated their profile": "%{name} updated their profile",
+        "%n files": [[1, 1, "%n file"], [2, null, "%n files"]]
+    }
+}`
+	de := `{
+    "values": {
+        "%{name} updated their profile": {
+            "select": "gender",
+            "cases": {
+                "male": "%{name} hat sein Profil aktualisiert",
+                "other": "Das Profil wurde aktualisiert"
+            }
+        },
+        "%n files": {"select": "case", "cases": {"other": [[1, 1, "%n Datei"], [2, null, "%n Dateien"]]}}
+    }
+}`
+	problems, err := Compare(strings.NewReader(en), strings.NewReader(de))
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Select forms should be compared by placeholders of all cases, but %v", problems)
+	}
+	ja := fmt.Sprintf(`{
+    "values": {"%%n files": "%%n ファイル"},
+    "meta": {"%%n files": {"source": "%s"}}
+}`, SourceHash([]string{"%n Datei", "%n Dateien"}))
+	problems, err = Compare(strings.NewReader(`{"values": {"%n files": {"select": "case", "cases": {"other": [[1, 1, "%n Datei"], [2, null, "%n Dateien"]]}}}}`), strings.NewReader(ja))
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Source hash should include texts of select cases, but %v", problems)
+	}
+}
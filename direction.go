@@ -0,0 +1,92 @@
+package i18n4v
+
+import (
+	"fmt"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// Direction is a text direction of translator's language.
+// Its value can be used as dir attribute of HTML.
+type Direction string
+
+const (
+	// LeftToRight is a direction of languages like English and Japanese.
+	LeftToRight Direction = "ltr"
+	// RightToLeft is a direction of languages like Arabic and Hebrew.
+	RightToLeft Direction = "rtl"
+)
+
+const (
+	firstStrongIsolate    = "\u2068"
+	popDirectionalIsolate = "\u2069"
+)
+
+var rightToLeftScripts = map[string]bool{
+	"Adlm": true, // Adlam
+	"Arab": true, // Arabic
+	"Hebr": true, // Hebrew
+	"Mand": true, // Mandaic
+	"Mend": true, // Mende Kikakui
+	"Nkoo": true, // N'Ko
+	"Rohg": true, // Hanifi Rohingya
+	"Samr": true, // Samaritan
+	"Syrc": true, // Syriac
+	"Thaa": true, // Thaana
+	"Yezi": true, // Yezidi
+}
+
+func tagDirection(tag language.Tag) Direction {
+	script, _ := tag.Script()
+	if rightToLeftScripts[script.String()] {
+		return RightToLeft
+	}
+	return LeftToRight
+}
+
+func (t *Translator) setTag(tag language.Tag) {
+	t.tag = tag
+	t.direction = tagDirection(tag)
+}
+
+/*
+Direction returns text direction of translator's language ("ltr" or "rtl").
+
+Language is specified when registering translator via Add functions or creating via Create functions.
+If it is not specified, it returns LeftToRight.
+*/
+func (t *Translator) Direction() Direction {
+	if t.direction == "" {
+		return LeftToRight
+	}
+	return t.direction
+}
+
+// isolate returns true if replacement values should be isolated from translation text.
+func (t *Translator) isolate() bool {
+	return t.direction == RightToLeft
+}
+
+// hasStrongDirection checks whether text contains characters that have strong direction like alphabets.
+// Numbers and symbols don't need isolation.
+func hasStrongDirection(text string) bool {
+	for _, r := range text {
+		properties, _ := bidi.LookupRune(r)
+		switch properties.Class() {
+		case bidi.L, bidi.R, bidi.AL:
+			return true
+		}
+	}
+	return false
+}
+
+// formatValue converts replacement value into string.
+// If isolate is true, the value is wrapped with FSI/PDI marks to avoid scrambling text order
+// when LTR text (like product names) is inserted into RTL translation.
+func formatValue(value interface{}, isolate bool) string {
+	text := fmt.Sprintf("%v", value)
+	if isolate && hasStrongDirection(text) {
+		return firstStrongIsolate + text + popDirectionalIsolate
+	}
+	return text
+}
@@ -0,0 +1,40 @@
+package i18n4v
+
+import (
+	"golang.org/x/text/language"
+	"testing"
+)
+
+func TestDirection(t *testing.T) {
+	ar := MustCreateFromString(`{
+        "values": {
+            "Welcome to %{product}": "مرحبا بكم في %{product}",
+            "%n items": [
+                [0, null, "%n عناصر"]
+            ]
+        }
+    }`, language.Arabic)
+	if ar.Direction() != RightToLeft {
+		t.Errorf("Arabic translator should be RightToLeft, but %s", ar.Direction())
+	}
+	if r := ar.Translate("Welcome to %{product}", Replace{"product": "i18n4v"}); r != "مرحبا بكم في \u2068i18n4v\u2069" {
+		t.Errorf("It should isolate inserted LTR value, but %q", r)
+	}
+	if r := ar.Translate("%n items", 3); r != "3 عناصر" {
+		t.Errorf("It should not isolate numbers, but %q", r)
+	}
+
+	en := MustCreateFromString(`{}`, language.English)
+	if en.Direction() != LeftToRight {
+		t.Errorf("English translator should be LeftToRight, but %s", en.Direction())
+	}
+	if r := en.Translate("Welcome to %{product}", Replace{"product": "i18n4v"}); r != "Welcome to i18n4v" {
+		t.Errorf("It should not isolate values in LTR translator, but %q", r)
+	}
+	if d := MustCreateFromString(`{}`, language.Hebrew).Direction(); d != RightToLeft {
+		t.Errorf("Hebrew translator should be RightToLeft, but %s", d)
+	}
+	if d := MustCreateFromString(`{}`).Direction(); d != LeftToRight {
+		t.Errorf("Translator without language should be LeftToRight, but %s", d)
+	}
+}
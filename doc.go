@@ -93,6 +93,50 @@ Of cource, you can use all features together that are described before:
         Context{"gender": "female" })
     // -> Jane uploaded 4 photos to her Hen's Night album
 
+If several contexts match, translations are searched from the most specific context (that has
+the most conditions) to the least specific one, and then root values.
+
+Select forms choose a translation of one phrase without duplicating context blocks.
+The case is selected by the value in Context or Replace, and "other" is used if no case matches.
+Cases can be select forms (like grammatical case and gender) or pluralisation:
+
+    MustAddFromString(`{
+        "values": {
+            "%{name} updated their profile": {
+                "select": "gender",
+                "cases": {
+                    "male": "%{name} hat sein Profil aktualisiert",
+                    "female": "%{name} hat ihr Profil aktualisiert",
+                    "other": "%{name} hat das Profil aktualisiert"
+                }
+            }
+        }
+    }`)
+
+    _("%{name} updated their profile", Replace{"name": "Anna", "gender": "female"})
+    // -> Anna hat ihr Profil aktualisiert
+
+Large applications can split dictionaries into namespaces to avoid collisions of short keys.
+Translator returned by Namespace method searches the namespace first and then the common namespace:
+
+    i18n4v.MustAddToNamespace("billing", reader, language.Japanese)
+    billing := i18n4v.SelectTranslator("ja").Namespace("billing")
+    billing.Translate("Save")
+
+Applications that have many locales can register loaders instead of dictionaries.
+Dictionaries are loaded when the locale is selected at the first time, and UnloadUnused unloads them
+that have not been used for a while:
+
+    i18n4v.AddLoader(language.Japanese, i18n4v.FSLoader(locales, "locales/ja.json"))
+
+Metrics counts hits, misses, context hits and fallbacks of Translate per locale.
+It can be exported via expvar, and hooks receive each lookup. UsageLog records keys used in production
+for i18n4vgo prune and coverage commands:
+
+    metrics := i18n4v.NewMetrics(i18n4v.NewUsageLog(logFile, 100))
+    metrics.Publish("i18n4v")
+    i18n4v.SetMetrics(metrics)
+
 This package is released under MIT license.
 */
 package i18n4v
@@ -0,0 +1,380 @@
+package i18n4v
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"reflect"
+	"time"
+)
+
+// MergePolicy decides what Merge does when both translators have the same key with different translations.
+type MergePolicy int
+
+const (
+	// KeepExisting keeps the translation of the receiver.
+	KeepExisting MergePolicy = iota
+	// Overwrite uses the translation of the merged translator.
+	Overwrite
+	// ErrorOnConflict makes Merge return error without any modification.
+	ErrorOnConflict
+)
+
+// lastModified returns the time when the dictionary (or one of its namespaces) was modified.
+func (t *Translator) lastModified() time.Time {
+	t = t.source()
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	result := t.modified
+	for _, namespace := range t.namespaces {
+		if modified := namespace.lastModified(); modified.After(result) {
+			result = modified
+		}
+	}
+	return result
+}
+
+func newTranslation(key string, value interface{}) (*translation, error) {
+	switch v := value.(type) {
+	case string:
+		return &translation{translation: v}, nil
+	case []Pluralisation:
+		if len(v) == 0 {
+			return nil, errors.Errorf("pluralisation of key '%s' is empty", key)
+		}
+		result := &translation{}
+		for _, pluralisation := range v {
+			if pluralisation.Min > pluralisation.Max {
+				return nil, errors.Errorf("pluralisation range of key '%s' is empty: [%d, %d]", key, pluralisation.Min, pluralisation.Max)
+			}
+			result.pluralisations = append(result.pluralisations, &pluralisationEntry{
+				min:         pluralisation.Min,
+				max:         pluralisation.Max,
+				translation: pluralisation.Translation,
+			})
+		}
+		return result, nil
+	case SelectForm:
+		if v.Name == "" || len(v.Cases) == 0 {
+			return nil, errors.Errorf("select form of key '%s' should have name and cases", key)
+		}
+		selector := &selectorEntry{name: v.Name, cases: make(map[string]*translation, len(v.Cases))}
+		for caseName, caseValue := range v.Cases {
+			entry, err := newTranslation(key, caseValue)
+			if err != nil {
+				return nil, err
+			}
+			selector.cases[caseName] = entry
+		}
+		return &translation{selector: selector}, nil
+	}
+	return nil, errors.Errorf("value of key '%s' should be string, []Pluralisation or SelectForm, but '%v'", key, value)
+}
+
+func copyTranslation(value *translation) *translation {
+	result := &translation{translation: value.translation}
+	for _, pluralisation := range value.pluralisations {
+		entry := *pluralisation
+		result.pluralisations = append(result.pluralisations, &entry)
+	}
+	if value.selector != nil {
+		result.selector = &selectorEntry{name: value.selector.name, cases: make(map[string]*translation, len(value.selector.cases))}
+		for caseName, caseValue := range value.selector.cases {
+			result.selector.cases[caseName] = copyTranslation(caseValue)
+		}
+	}
+	return result
+}
+
+func copyContext(context Context) Context {
+	result := make(Context, len(context))
+	for k, v := range context {
+		result[k] = v
+	}
+	return result
+}
+
+// findContext returns context entry that has the same matches.
+func (t *Translator) findContext(matches Context) (int, *contextEntry) {
+	for i, context := range t.contexts {
+		if sameContext(context.matches, matches) {
+			return i, context
+		}
+	}
+	return -1, nil
+}
+
+/*
+AddPluralisation method adds pluralisation entry to root values of the dictionary.
+If the key already exists, it is replaced.
+
+Use math.MinInt64 and math.MaxInt64 for the bounds that are null in JSON.
+*/
+func (t *Translator) AddPluralisation(key string, pluralisations ...Pluralisation) error {
+	value, err := newTranslation(key, pluralisations)
+	if err != nil {
+		return err
+	}
+	t = t.source()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.values[key] = value
+	t.modified = time.Now()
+	return nil
+}
+
+/*
+AddContextWord method adds translation to the context that has the matches.
+If the context doesn't exist, it is created. If the key already exists in the context, it is replaced.
+
+value should be string, []Pluralisation or SelectForm.
+*/
+func (t *Translator) AddContextWord(matches Context, key string, value interface{}) error {
+	entry, err := newTranslation(key, value)
+	if err != nil {
+		return err
+	}
+	t = t.source()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	_, context := t.findContext(matches)
+	if context == nil {
+		context = &contextEntry{
+			matches: copyContext(matches),
+			values:  make(map[string]*translation),
+		}
+		t.contexts = append(t.contexts, context)
+	}
+	context.values[key] = entry
+	t.modified = time.Now()
+	return nil
+}
+
+/*
+SetContext method replaces all translations of the context that has the matches.
+If the context doesn't exist, it is added.
+
+Values should be string, []Pluralisation or SelectForm.
+*/
+func (t *Translator) SetContext(matches Context, values map[string]interface{}) error {
+	context := &contextEntry{
+		matches: copyContext(matches),
+		values:  make(map[string]*translation, len(values)),
+	}
+	for key, value := range values {
+		entry, err := newTranslation(key, value)
+		if err != nil {
+			return err
+		}
+		context.values[key] = entry
+	}
+	t = t.source()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if i, _ := t.findContext(matches); i != -1 {
+		t.contexts[i] = context
+	} else {
+		t.contexts = append(t.contexts, context)
+	}
+	t.modified = time.Now()
+	return nil
+}
+
+/*
+RemoveWord method removes the key from root values of the dictionary.
+It returns false if the key doesn't exist.
+*/
+func (t *Translator) RemoveWord(key string) bool {
+	t = t.source()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, ok := t.values[key]; !ok {
+		return false
+	}
+	delete(t.values, key)
+	t.modified = time.Now()
+	return true
+}
+
+/*
+RemoveContextWord method removes the key from the context that has the matches.
+It returns false if the key doesn't exist.
+*/
+func (t *Translator) RemoveContextWord(matches Context, key string) bool {
+	t = t.source()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	_, context := t.findContext(matches)
+	if context == nil {
+		return false
+	}
+	if _, ok := context.values[key]; !ok {
+		return false
+	}
+	delete(context.values, key)
+	t.modified = time.Now()
+	return true
+}
+
+/*
+RemoveContext method removes the context that has the matches.
+It returns false if the context doesn't exist.
+*/
+func (t *Translator) RemoveContext(matches Context) bool {
+	t = t.source()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	i, _ := t.findContext(matches)
+	if i == -1 {
+		return false
+	}
+	t.contexts = append(t.contexts[:i:i], t.contexts[i+1:]...)
+	t.modified = time.Now()
+	return true
+}
+
+// mergeValues merges values. If dryRun is true, it only checks conflicts.
+func mergeValues(dest, src map[string]*translation, policy MergePolicy, context string, dryRun bool) error {
+	for key, value := range src {
+		if existing, ok := dest[key]; ok && !reflect.DeepEqual(existing, value) {
+			switch policy {
+			case KeepExisting:
+				continue
+			case ErrorOnConflict:
+				return errors.Errorf("key '%s' at %s conflicts", key, context)
+			}
+		}
+		if !dryRun {
+			dest[key] = copyTranslation(value)
+		}
+	}
+	return nil
+}
+
+// mergeSnapshot is a copy of dictionary that is merged by Merge method.
+type mergeSnapshot struct {
+	values     map[string]*translation
+	contexts   []*contextEntry
+	htmlKeys   []string
+	namespaces map[string]*mergeSnapshot
+}
+
+// snapshot copies dictionary of t and its namespaces.
+// translation instances are not modified after registration, so shallow copy is enough.
+func (t *Translator) snapshot() *mergeSnapshot {
+	t.mutex.RLock()
+	result := &mergeSnapshot{
+		values:   make(map[string]*translation, len(t.values)),
+		contexts: make([]*contextEntry, len(t.contexts)),
+	}
+	for key, value := range t.values {
+		result.values[key] = value
+	}
+	for i, context := range t.contexts {
+		result.contexts[i] = &contextEntry{
+			matches: copyContext(context.matches),
+			values:  make(map[string]*translation, len(context.values)),
+		}
+		for key, value := range context.values {
+			result.contexts[i].values[key] = value
+		}
+	}
+	for key := range t.htmlKeys {
+		result.htmlKeys = append(result.htmlKeys, key)
+	}
+	namespaces := make(map[string]*Translator, len(t.namespaces))
+	for name, namespace := range t.namespaces {
+		namespaces[name] = namespace
+	}
+	t.mutex.RUnlock()
+
+	for name, namespace := range namespaces {
+		if result.namespaces == nil {
+			result.namespaces = make(map[string]*mergeSnapshot)
+		}
+		result.namespaces[name] = namespace.snapshot()
+	}
+	return result
+}
+
+// checkMerge returns error if snapshot conflicts with t. Caller should lock the mutex of t.
+func (t *Translator) checkMerge(snapshot *mergeSnapshot, policy MergePolicy, location string) error {
+	if err := mergeValues(t.values, snapshot.values, policy, location+"root values", true); err != nil {
+		return err
+	}
+	for _, context := range snapshot.contexts {
+		if _, existing := t.findContext(context.matches); existing != nil {
+			if err := mergeValues(existing.values, context.values, policy, location+"context", true); err != nil {
+				return err
+			}
+		}
+	}
+	for name, namespace := range snapshot.namespaces {
+		existing, ok := t.namespaces[name]
+		if !ok {
+			continue
+		}
+		existing.mutex.RLock()
+		err := existing.checkMerge(namespace, policy, fmt.Sprintf("%snamespace '%s' ", location, name))
+		existing.mutex.RUnlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyMerge merges snapshot into t. Caller should lock the mutex of t.
+func (t *Translator) applyMerge(snapshot *mergeSnapshot, policy MergePolicy) {
+	mergeValues(t.values, snapshot.values, policy, "root values", false)
+	for _, context := range snapshot.contexts {
+		_, existing := t.findContext(context.matches)
+		if existing == nil {
+			existing = &contextEntry{
+				matches: context.matches,
+				values:  make(map[string]*translation, len(context.values)),
+			}
+			t.contexts = append(t.contexts, existing)
+		}
+		mergeValues(existing.values, context.values, policy, "context", false)
+	}
+	for _, key := range snapshot.htmlKeys {
+		if t.htmlKeys == nil {
+			t.htmlKeys = make(map[string]bool)
+		}
+		t.htmlKeys[key] = true
+	}
+	for name, namespace := range snapshot.namespaces {
+		existing := t.namespaceDictionary(name)
+		existing.mutex.Lock()
+		existing.applyMerge(namespace, policy)
+		existing.mutex.Unlock()
+	}
+	t.modified = time.Now()
+}
+
+/*
+Merge method merges translations of other translator into this translator.
+policy decides what to do when both translators have the same key with different translations.
+Namespaces are merged with the same policy. Missing namespaces are created.
+
+If policy is ErrorOnConflict and conflicts are found, it returns error and the receiver is not modified.
+*/
+func (t *Translator) Merge(other *Translator, policy MergePolicy) error {
+	t = t.source()
+	other = other.source()
+	if t == other {
+		return nil
+	}
+	// copy other's dictionary first to avoid dead lock when two translators merge each other
+	snapshot := other.snapshot()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if policy == ErrorOnConflict {
+		if err := t.checkMerge(snapshot, policy, ""); err != nil {
+			return err
+		}
+	}
+	t.applyMerge(snapshot, policy)
+	return nil
+}
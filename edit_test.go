@@ -0,0 +1,173 @@
+package i18n4v
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestEdit(t *testing.T) {
+	ja := MustCreateFromString(`{
+        "values": {
+            "Cancel": "キャンセル"
+        }
+    }`)
+	err := ja.AddPluralisation("%n comments",
+		Pluralisation{0, 0, "コメントなし"},
+		Pluralisation{1, math.MaxInt64, "%n 件のコメント"})
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	if r := ja.Translate("%n comments", 3); r != "3 件のコメント" {
+		t.Errorf("It should use added pluralisation, but %s", r)
+	}
+	if err := ja.AddPluralisation("%n likes", Pluralisation{2, 1, "wrong"}); err == nil {
+		t.Errorf("It should return error for empty range")
+	}
+	female := Context{"gender": "female"}
+	ja.AddContextWord(female, "Hello", "こんにちは(女性)")
+	if r := ja.Translate("Hello", Replace{}, female); r != "こんにちは(女性)" {
+		t.Errorf("It should use added context word, but %s", r)
+	}
+	ja.SetContext(female, map[string]interface{}{"Goodbye": "さようなら(女性)"})
+	if r := ja.Translate("Hello", Replace{}, female); r != "Hello" {
+		t.Errorf("It should replace context, but %s", r)
+	}
+	if !ja.RemoveContextWord(female, "Goodbye") || ja.RemoveContextWord(female, "Goodbye") {
+		t.Errorf("It should remove context word only once")
+	}
+	if !ja.RemoveContext(female) || len(ja.Contexts()) != 0 {
+		t.Errorf("It should remove context")
+	}
+	if !ja.RemoveWord("Cancel") || ja.Translate("Cancel") != "Cancel" {
+		t.Errorf("It should remove word")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := `{
+        "values": {
+            "Cancel": "キャンセル",
+            "Save": "保存"
+        }
+    }`
+	other := MustCreateFromString(`{
+        "values": {
+            "Cancel": "取り消し",
+            "Save": "保存",
+            "Delete": "削除"
+        },
+        "contexts": [
+            {
+                "matches": {"gender": "female"},
+                "values": {
+                    "Hello": "こんにちは(女性)"
+                }
+            }
+        ]
+    }`)
+
+	keep := MustCreateFromString(base)
+	keep.Merge(other, KeepExisting)
+	if keep.Translate("Cancel") != "キャンセル" || keep.Translate("Delete") != "削除" {
+		t.Errorf("It should keep existing words and add new words")
+	}
+	if r := keep.Translate("Hello", Replace{}, Context{"gender": "female"}); r != "こんにちは(女性)" {
+		t.Errorf("It should merge contexts, but %s", r)
+	}
+
+	overwrite := MustCreateFromString(base)
+	overwrite.Merge(other, Overwrite)
+	if overwrite.Translate("Cancel") != "取り消し" {
+		t.Errorf("It should overwrite existing words")
+	}
+
+	conflict := MustCreateFromString(base)
+	if err := conflict.Merge(other, ErrorOnConflict); err == nil {
+		t.Errorf("It should return error for conflict")
+	}
+	if conflict.Translate("Delete") != "Delete" {
+		t.Errorf("It should not modify translator when conflict is found")
+	}
+}
+
+func TestMergeNamespaces(t *testing.T) {
+	base := `{
+        "values": {"Save": "保存"},
+        "namespaces": {
+            "billing": {"values": {"Save": "支払いを保存", "Pay": "支払う"}}
+        }
+    }`
+	other := MustCreateFromString(`{
+        "namespaces": {
+            "billing": {"values": {"Save": "支払いを記録", "Refund": "返金"}},
+            "admin": {"values": {"Save": "設定を保存"}}
+        }
+    }`)
+
+	overwrite := MustCreateFromString(base)
+	if err := overwrite.Merge(other, Overwrite); err != nil {
+		t.Fatal(err)
+	}
+	if names := overwrite.Namespaces(); !reflect.DeepEqual(names, []string{"admin", "billing"}) {
+		t.Errorf("It should create missing namespaces, but %v", names)
+	}
+	billing := overwrite.Namespace("billing")
+	if r := billing.Translate("Save"); r != "支払いを記録" {
+		t.Errorf("It should overwrite namespaced words, but %s", r)
+	}
+	if billing.Translate("Pay") != "支払う" || billing.Translate("Refund") != "返金" {
+		t.Errorf("It should keep existing namespaced words and add new ones")
+	}
+	if r := overwrite.Namespace("admin").Translate("Save"); r != "設定を保存" {
+		t.Errorf("It should merge new namespace, but %s", r)
+	}
+	if r := overwrite.Translate("Save"); r != "保存" {
+		t.Errorf("It should not change the common namespace, but %s", r)
+	}
+
+	keep := MustCreateFromString(base)
+	keep.Merge(other, KeepExisting)
+	if r := keep.Namespace("billing").Translate("Save"); r != "支払いを保存" {
+		t.Errorf("It should keep existing namespaced words, but %s", r)
+	}
+
+	conflict := MustCreateFromString(base)
+	if err := conflict.Merge(other, ErrorOnConflict); err == nil || !strings.Contains(err.Error(), "namespace 'billing'") {
+		t.Errorf("It should return error for conflict in namespace, but %v", err)
+	}
+	if names := conflict.Namespaces(); !reflect.DeepEqual(names, []string{"billing"}) {
+		t.Errorf("It should not modify translator when conflict is found, but %v", names)
+	}
+	if r := conflict.Namespace("billing").Translate("Refund"); r != "Refund" {
+		t.Errorf("It should not modify namespace when conflict is found, but %s", r)
+	}
+}
+
+func TestEditConcurrently(t *testing.T) {
+	ja := MustCreateFromString(`{}`)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("key%d-%d", i, j)
+				ja.AddWord(key, "value")
+				ja.AddContextWord(Context{"n": fmt.Sprint(j % 3)}, key, "value")
+				ja.RemoveWord(key)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ja.Translate(fmt.Sprintf("key%d-%d", i, j), Replace{}, Context{"n": "1"})
+				ja.Keys()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,210 @@
+package i18n4v
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+type exportContext struct {
+	Matches Context                `json:"matches"`
+	Values  map[string]interface{} `json:"values"`
+}
+
+type exportDictionary struct {
+	Values   map[string]interface{} `json:"values,omitempty"`
+	Contexts []*exportContext       `json:"contexts,omitempty"`
+	HTML     []string               `json:"html,omitempty"`
+	// Namespaces keeps dictionaries of namespaces.
+	Namespaces map[string]*exportDictionary `json:"namespaces,omitempty"`
+}
+
+func exportBound(value, nullValue int64) interface{} {
+	if value == nullValue {
+		return nil
+	}
+	return value
+}
+
+func exportTranslation(value *translation) interface{} {
+	if value.selector != nil {
+		cases := make(map[string]interface{}, len(value.selector.cases))
+		for caseName, caseValue := range value.selector.cases {
+			cases[caseName] = exportTranslation(caseValue)
+		}
+		return map[string]interface{}{
+			"select": value.selector.name,
+			"cases":  cases,
+		}
+	}
+	if len(value.pluralisations) == 0 {
+		return value.translation
+	}
+	result := make([]interface{}, len(value.pluralisations))
+	for i, pluralisation := range value.pluralisations {
+		result[i] = []interface{}{
+			exportBound(pluralisation.min, math.MinInt64),
+			exportBound(pluralisation.max, math.MaxInt64),
+			pluralisation.translation,
+		}
+	}
+	return result
+}
+
+func exportValues(values map[string]*translation, filter func(key string) bool) map[string]interface{} {
+	result := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if filter == nil || filter(key) {
+			result[key] = exportTranslation(value)
+		}
+	}
+	return result
+}
+
+// export converts dictionary into the structure of JSON format that add reads.
+// If filter is not nil, only keys that filter returns true are exported.
+func (t *Translator) export(filter func(key string) bool) *exportDictionary {
+	t = t.source()
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	result := &exportDictionary{
+		Values: exportValues(t.values, filter),
+	}
+	for _, context := range t.contexts {
+		values := exportValues(context.values, filter)
+		if filter != nil && len(values) == 0 {
+			continue
+		}
+		result.Contexts = append(result.Contexts, &exportContext{
+			Matches: context.matches,
+			Values:  values,
+		})
+	}
+	for key := range t.htmlKeys {
+		if filter == nil || filter(key) {
+			result.HTML = append(result.HTML, key)
+		}
+	}
+	sort.Strings(result.HTML)
+	for name, namespace := range t.namespaces {
+		if result.Namespaces == nil {
+			result.Namespaces = make(map[string]*exportDictionary)
+		}
+		result.Namespaces[name] = namespace.export(filter)
+	}
+	return result
+}
+
+// prefixFilter returns filter function that accepts keys that start with one of prefixes.
+func prefixFilter(prefixes []string) func(key string) bool {
+	if len(prefixes) == 0 {
+		return nil
+	}
+	return func(key string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+/*
+MarshalJSON method returns dictionary in JSON format that Create and Add accept.
+Namespaces are written in "namespaces" section.
+Translator scoped to a namespace writes only the dictionary of the namespace.
+
+Bounds of pluralisation that are math.MinInt64 or math.MaxInt64 are written as null,
+and contexts keep registered order. So you can save translations edited at runtime (like via AddWord).
+*/
+func (t *Translator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.export(nil))
+}
+
+/*
+WriteTo method writes dictionary in indented JSON format that Create and Add accept.
+It implements io.WriterTo interface.
+*/
+func (t *Translator) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(t.export(nil))
+	if err != nil {
+		return 0, err
+	}
+	_, node, err := parseJSONNode(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	var buffer bytes.Buffer
+	writeIndentedJSON(&buffer, node, "")
+	buffer.WriteString("\n")
+	return buffer.WriteTo(w)
+}
+
+// writeIndentedJSON writes JSON with indentation.
+// Arrays of scalar values like pluralisation entries are written in one line.
+func writeIndentedJSON(buffer *bytes.Buffer, node *jsonNode, indent string) {
+	const unit = "    "
+	switch {
+	case node.isObj:
+		members := node.members()
+		if len(members) == 0 {
+			buffer.WriteString("{}")
+			return
+		}
+		buffer.WriteString("{\n")
+		for i, member := range members {
+			buffer.WriteString(indent + unit)
+			writeJSONScalar(buffer, member.key)
+			buffer.WriteString(": ")
+			writeIndentedJSON(buffer, member.value, indent+unit)
+			if i < len(members)-1 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(indent + "}")
+	case node.isArray:
+		items := node.array()
+		inline := true
+		for _, item := range items {
+			if item.isArray || item.isObj {
+				inline = false
+			}
+		}
+		if inline {
+			buffer.WriteString("[")
+			for i, item := range items {
+				if i > 0 {
+					buffer.WriteString(", ")
+				}
+				writeJSONScalar(buffer, item.value)
+			}
+			buffer.WriteString("]")
+			return
+		}
+		buffer.WriteString("[\n")
+		for i, item := range items {
+			buffer.WriteString(indent + unit)
+			writeIndentedJSON(buffer, item, indent+unit)
+			if i < len(items)-1 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(indent + "]")
+	default:
+		writeJSONScalar(buffer, node.value)
+	}
+}
+
+func writeJSONScalar(buffer *bytes.Buffer, value interface{}) {
+	var temp bytes.Buffer
+	enc := json.NewEncoder(&temp)
+	enc.SetEscapeHTML(false)
+	enc.Encode(value)
+	buffer.Write(bytes.TrimRight(temp.Bytes(), "\n"))
+}
@@ -0,0 +1,74 @@
+package i18n4v
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	src := `{
+    "values": {
+        "Due in %n days": [
+            [null, -2, "Due -%n days ago"],
+            [-1, -1, "Due Yesterday"],
+            [0, 0, "Due Today"],
+            [1, 1, "Due Tomorrow"],
+            [2, null, "Due in %n days"]
+        ],
+        "Hello": "こんにちは",
+        "Welcome %{name}": {
+            "cases": {
+                "female": "%{name}さん(女性)、ようこそ",
+                "other": "%{name}さん、ようこそ"
+            },
+            "select": "gender"
+        }
+    },
+    "contexts": [
+        {
+            "matches": {
+                "gender": "male"
+            },
+            "values": {
+                "Hello": "こんにちは(男性)"
+            }
+        },
+        {
+            "matches": {
+                "gender": "female"
+            },
+            "values": {
+                "Hello": "こんにちは(女性)"
+            }
+        }
+    ],
+    "html": ["<b>Hello</b>"]
+}
+`
+	ja := MustCreateFromString(src)
+	var buffer bytes.Buffer
+	_, err := ja.WriteTo(&buffer)
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	if buffer.String() != src {
+		t.Errorf("It should write the same JSON, but\n%s\n!=\n%s", buffer.String(), src)
+	}
+
+	ja.AddWord("Cancel", "キャンセル")
+	data, err := json.Marshal(ja)
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	restored := MustCreate(bytes.NewReader(data))
+	if r := restored.Translate("Cancel"); r != "キャンセル" {
+		t.Errorf("It should keep added words, but %s", r)
+	}
+	if r := restored.Translate("Due in %n days", -5); r != "Due 5 days ago" {
+		t.Errorf("It should keep null bounds, but %s", r)
+	}
+	if r := restored.Translate("Hello", Replace{}, Context{"gender": "female"}); r != "こんにちは(女性)" {
+		t.Errorf("It should keep contexts, but %s", r)
+	}
+}
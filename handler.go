@@ -0,0 +1,125 @@
+package i18n4v
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/text/language"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/*
+DictionaryHandler is an http.Handler that serves the registered dictionary as JSON.
+The JSON format is the same as the one that JavaScript runtime's i18n.translator.add() and
+i18n.create() accept. So Go server and JavaScript front-end can share the same dictionaries.
+
+The language is selected by "lang" query parameter or Accept-Language header.
+If no language is registered, it serves the default translator's dictionary.
+
+It supports ETag, Last-Modified and gzip compression.
+*/
+type DictionaryHandler struct {
+	// Prefixes filters keys. If it is not empty, only keys that start with one of them are served.
+	// "prefix" query parameters can be used too.
+	Prefixes []string
+	// Namespace selects the namespace to serve. If it is empty, the common namespace and all namespaces are served.
+	// "namespace" query parameter can be used too. Unknown namespaces are not found (404).
+	Namespace string
+}
+
+// NewDictionaryHandler returns DictionaryHandler that serves keys that start with one of prefixes.
+func NewDictionaryHandler(prefixes ...string) *DictionaryHandler {
+	return &DictionaryHandler{
+		Prefixes: prefixes,
+	}
+}
+
+func (h *DictionaryHandler) selectTranslator(r *http.Request) (language.Tag, *Translator) {
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		lang = r.Header.Get("Accept-Language")
+	}
+	tag := SelectTag(lang)
+	if translator, err := registeredTranslator(tag); err == nil && translator != nil {
+		return tag, translator
+	}
+	return tag, defaultTranslator
+}
+
+func (h *DictionaryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	tag, translator := h.selectTranslator(r)
+	namespace := h.Namespace
+	if value := r.URL.Query().Get("namespace"); value != "" {
+		namespace = value
+	}
+	if namespace != "" {
+		scoped, ok := translator.LookupNamespace(namespace)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		translator = scoped
+	}
+	prefixes := append(append([]string{}, h.Prefixes...), r.URL.Query()["prefix"]...)
+	body, err := json.Marshal(translator.export(prefixFilter(prefixes)))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum(body))
+	modified := translator.lastModified().UTC().Truncate(time.Second)
+
+	header := w.Header()
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("ETag", etag)
+	header.Add("Vary", "Accept-Language")
+	header.Add("Vary", "Accept-Encoding")
+	if tag != language.Und {
+		header.Set("Content-Language", tag.String())
+	}
+	if !modified.IsZero() {
+		header.Set("Last-Modified", modified.Format(http.TimeFormat))
+	}
+	if notModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		var buffer bytes.Buffer
+		gz := gzip.NewWriter(&buffer)
+		gz.Write(body)
+		gz.Close()
+		body = buffer.Bytes()
+		header.Set("Content-Encoding", "gzip")
+	}
+	header.Set("Content-Length", fmt.Sprint(len(body)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(body)
+}
+
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.IsZero() {
+		return !modified.After(since)
+	}
+	return false
+}
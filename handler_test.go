@@ -0,0 +1,123 @@
+package i18n4v
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"golang.org/x/text/language"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDictionaryHandler(t *testing.T) {
+	MustAddFromString(`{
+        "values": {
+            "Cancel": "Cancel",
+            "billing.Total": "Total"
+        }
+    }`, language.English)
+	MustAddFromString(`{
+        "values": {
+            "Cancel": "キャンセル",
+            "billing.Total": "合計",
+            "%n comments": [
+                [0, null, "%n コメント"]
+            ]
+        },
+        "contexts": [
+            {
+                "matches": {"gender": "female"},
+                "values": {
+                    "billing.Total": "合計(女性)"
+                }
+            }
+        ]
+    }`, language.Japanese)
+	defer Reset()
+
+	server := httptest.NewServer(NewDictionaryHandler())
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("Accept-Language", "ja-JP")
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	expected := `{"values":{"%n comments":[[0,null,"%n コメント"]],"Cancel":"キャンセル","billing.Total":"合計"},"contexts":[{"matches":{"gender":"female"},"values":{"billing.Total":"合計(女性)"}}]}`
+	if string(body) != expected {
+		t.Errorf("It should return dictionary, but\n%s\n!=\n%s", string(body), expected)
+	}
+	if res.Header.Get("Content-Language") != "ja" || res.Header.Get("ETag") == "" || res.Header.Get("Last-Modified") == "" {
+		t.Errorf("It should return headers, but %v", res.Header)
+	}
+
+	req.Header.Set("If-None-Match", res.Header.Get("ETag"))
+	res, err = http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotModified {
+		t.Errorf("It should return 304, but %d", res.StatusCode)
+	}
+
+	req, _ = http.NewRequest("GET", server.URL+"?lang=en&prefix=billing.", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res, err = http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("It should compress response, but %v", res.Header)
+	}
+	gz, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dict map[string]map[string]string
+	json.NewDecoder(gz).Decode(&dict)
+	res.Body.Close()
+	if len(dict["values"]) != 1 || dict["values"]["billing.Total"] != "Total" {
+		t.Errorf("It should filter keys by prefix, but %v", dict)
+	}
+}
+
+func TestDictionaryHandlerNamespace(t *testing.T) {
+	MustAddFromString(`{"values": {"Save": "保存"}}`, language.Japanese)
+	MustAddToNamespace("billing", strings.NewReader(`{"values": {"Save": "支払いを保存"}}`), language.Japanese)
+	defer Reset()
+
+	server := httptest.NewServer(NewDictionaryHandler())
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "?lang=ja&namespace=billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if !strings.Contains(string(body), "支払いを保存") {
+		t.Errorf("It should return dictionary of the namespace, but %s", string(body))
+	}
+
+	translator := SelectTranslator("ja")
+	for _, namespace := range []string{"x1", "x2", "x1"} {
+		res, err := http.Get(server.URL + "?lang=ja&namespace=" + namespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusNotFound {
+			t.Errorf("Unknown namespace should be not found, but %d", res.StatusCode)
+		}
+	}
+	if namespaces := translator.Namespaces(); !reflect.DeepEqual(namespaces, []string{"billing"}) {
+		t.Errorf("Unknown namespaces should not be created, but %v", namespaces)
+	}
+}
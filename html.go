@@ -0,0 +1,136 @@
+package i18n4v
+
+import (
+	"bytes"
+	"golang.org/x/net/html"
+	"io"
+	"strings"
+)
+
+func getAttr(node *html.Node, name string) (string, bool) {
+	for _, attr := range node.Attr {
+		if attr.Namespace == "" && attr.Key == name {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+func setAttr(node *html.Node, name, value string) {
+	for i, attr := range node.Attr {
+		if attr.Namespace == "" && attr.Key == name {
+			node.Attr[i].Val = value
+			return
+		}
+	}
+	node.Attr = append(node.Attr, html.Attribute{Key: name, Val: value})
+}
+
+func removeAttr(node *html.Node, name string) {
+	for i, attr := range node.Attr {
+		if attr.Namespace == "" && attr.Key == name {
+			node.Attr = append(node.Attr[:i], node.Attr[i+1:]...)
+			return
+		}
+	}
+}
+
+func innerHTML(node *html.Node) (string, error) {
+	var buffer bytes.Buffer
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		err := html.Render(&buffer, child)
+		if err != nil {
+			return "", err
+		}
+	}
+	return buffer.String(), nil
+}
+
+func removeChildren(node *html.Node) {
+	for node.FirstChild != nil {
+		node.RemoveChild(node.FirstChild)
+	}
+}
+
+func findI18nElements(node *html.Node, result []*html.Node) []*html.Node {
+	if node.Type == html.ElementNode {
+		if _, ok := getAttr(node, "data-i18n"); ok {
+			result = append(result, node)
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		result = findI18nElements(child, result)
+	}
+	return result
+}
+
+/*
+ApplyToHTML method translates contents of elements that have data-i18n attribute.
+It is a server side version of applyToHTML of JavaScript runtime and it works in the same way.
+
+data-i18n attribute value is used as a key. If it is empty, the inner text is used as a key.
+Translated text is escaped. If element has data-i18n-safe attribute, translated text is
+inserted as HTML.
+*/
+func (t *Translator) ApplyToHTML(doc *html.Node) error {
+	for _, elem := range findI18nElements(doc, nil) {
+		key, _ := getAttr(elem, "data-i18n")
+		_, hasSafe := getAttr(elem, "data-i18n-safe")
+		if key == "" {
+			child := elem.FirstChild
+			if child == nil || child.NextSibling != nil {
+				removeAttr(elem, "data-i18n")
+				continue
+			}
+			if child.Type != html.TextNode {
+				if !hasSafe {
+					removeAttr(elem, "data-i18n")
+					continue
+				}
+				var err error
+				key, err = innerHTML(elem)
+				if err != nil {
+					return err
+				}
+			} else {
+				key = child.Data
+				setAttr(elem, "data-i18n", key)
+			}
+		}
+		translated := t.Translate(key)
+		if hasSafe {
+			nodes, err := html.ParseFragment(strings.NewReader(translated), elem)
+			if err != nil {
+				return err
+			}
+			removeChildren(elem)
+			for _, node := range nodes {
+				elem.AppendChild(node)
+			}
+		} else {
+			// html.Render escapes &'"<> in text node
+			removeChildren(elem)
+			elem.AppendChild(&html.Node{
+				Type: html.TextNode,
+				Data: translated,
+			})
+		}
+	}
+	return nil
+}
+
+/*
+ApplyToHTMLDocument method reads HTML document from r, translates it like ApplyToHTML
+and writes the result to w.
+*/
+func (t *Translator) ApplyToHTMLDocument(w io.Writer, r io.Reader) error {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return err
+	}
+	err = t.ApplyToHTML(doc)
+	if err != nil {
+		return err
+	}
+	return html.Render(w, doc)
+}
@@ -0,0 +1,37 @@
+package i18n4v
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestApplyToHTML(t *testing.T) {
+	ja := MustCreateFromString(`{
+        "values": {
+            "Monty Python": "モンティ・パイソン",
+            "title": "<Flying Circus>",
+            "<b>Spam</b>": "<b>スパム</b>"
+        }
+    }`)
+	src := `<html><head></head><body>` +
+		`<h1 data-i18n>Monty Python</h1>` +
+		`<p data-i18n="title">Title</p>` +
+		`<p data-i18n data-i18n-safe><b>Spam</b></p>` +
+		`<p data-i18n><b>Eggs</b></p>` +
+		`</body></html>`
+	var buffer bytes.Buffer
+	err := ja.ApplyToHTMLDocument(&buffer, strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	expected := `<html><head></head><body>` +
+		`<h1 data-i18n="Monty Python">モンティ・パイソン</h1>` +
+		`<p data-i18n="title">&lt;Flying Circus&gt;</p>` +
+		`<p data-i18n="" data-i18n-safe=""><b>スパム</b></p>` +
+		`<p><b>Eggs</b></p>` +
+		`</body></html>`
+	if buffer.String() != expected {
+		t.Errorf("It should translate HTML, but\n%s\n!=\n%s", buffer.String(), expected)
+	}
+}
@@ -23,11 +23,37 @@ interface Context {
     [key: string]: string;
 }
 
+type Pluralisation = [number|null, number|null, string];
+
+interface SelectForm {
+    select: string;
+    cases: {
+        [caseName: string]: Translation;
+    };
+    fuzzy?: string[];
+}
+
+type Translation = string|Pluralisation[]|SelectForm;
+
+interface Values {
+    [key: string]: Translation;
+}
+
+interface Dictionary {
+    values?: Values;
+    contexts?: {
+        matches: Context;
+        values: Values;
+        fuzzy?: string[];
+    }[];
+    fuzzy?: string[];
+}
+
 declare class Translator {
     translate(text: string, defaultNumOrFormatting?: string|number|Formatting, numOrFormattingOrContext?: number|Formatting|Context, formattingOrContext?: Formatting|Context, context?: Context): string;
     addCallback(callback: UpdateCallback): CallbackKey;
     removeCallback(key: CallbackKey);
-    add(dict: any, lang?: string);
+    add(dict: Dictionary, lang?: string);
     setContext(key: string, value: string): void;
     clearContext(key: string): void;
     reset(): void;
@@ -39,7 +65,7 @@ declare class Translator {
 interface TranslatorFunction {
     (text: string, defaultNumOrFormatting?: string|number|Formatting, numOrFormattingOrContext?: number|Formatting|Context, formattingOrContext?: Formatting|Context, context?: Context): string;
     translator: Translator;
-    create(data: any): Translator; 
+    create(data: Dictionary): Translator; 
     selectLanguage(languages: string[], callback?: SelectLanguageCallback): Promise<string>;
     setLanguage(language: string): void;
 }
@@ -7,9 +7,12 @@ import (
 	"golang.org/x/text/language"
 	"io"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Replace type is used for passing replacement parameters when translating.
@@ -30,8 +33,18 @@ type pluralisationEntry struct {
 type translation struct {
 	translation    string
 	pluralisations []*pluralisationEntry
+	// selector chooses a nested translation by a value of Context or Replace (like gender or grammatical case).
+	selector *selectorEntry
 }
 
+type selectorEntry struct {
+	name  string
+	cases map[string]*translation
+}
+
+// otherCase is used by select forms when no case matches.
+const otherCase = "other"
+
 type contextEntry struct {
 	matches Context
 	values  map[string]*translation
@@ -46,6 +59,23 @@ type Translator struct {
 	values        map[string]*translation
 	globalContext Context
 	contexts      []*contextEntry
+	htmlKeys      map[string]bool
+	modified      time.Time
+	mutex         sync.RWMutex
+	tag           language.Tag
+	direction     Direction
+	// base is set when this translator wraps other translator (like pseudo localisation).
+	base *Translator
+	// filter converts selected translation before replacing placeholders.
+	filter func(text string) string
+	// namespaces keeps dictionaries of namespaces. It is used only in the root dictionary.
+	namespaces map[string]*Translator
+	// common is set when this translator is scoped to a namespace. It is searched after base.
+	common *Translator
+	// namespace is the name of namespace that this translator is scoped to.
+	namespace string
+	// metrics keeps *Metrics when metrics are enabled only for this translator.
+	metrics atomic.Value
 }
 
 var defaultFormatMap = Replace{}
@@ -124,24 +154,74 @@ func (t *Translator) Translate(text string, args ...interface{}) string {
 }
 
 func (t *Translator) translateText(text string, number int64, hasNumber bool, formatting Replace, context Context, defaultText string, hasDefaultText bool) string {
-	foundContext, ok := t.getContextData(context)
-	if ok {
-		result, ok := t.findTranslation(text, number, hasNumber, formatting, foundContext.values)
-		if ok {
+	for i, source := range t.dictionaries() {
+		result, found := t.findInDictionary(source, text, number, hasNumber, formatting, context)
+		if found != Miss {
+			if i > 0 {
+				found = Fallback
+			}
+			t.recordLookup(text, found)
 			return result
 		}
 	}
-	result, ok := t.findTranslation(text, number, hasNumber, formatting, t.values)
-	if ok {
-		return result
-	}
+	t.recordLookup(text, Miss)
 	if hasDefaultText {
 		return t.useOriginalText(defaultText, number, hasNumber, formatting)
 	}
 	return t.useOriginalText(text, number, hasNumber, formatting)
 }
 
-func (t *Translator) getContextData(context Context) (*contextEntry, bool) {
+// findInDictionary searches the text in matched contexts and then in root values of source.
+// If root values are used though contexts match, it returns Fallback.
+func (t *Translator) findInDictionary(source *Translator, text string, number int64, hasNumber bool, formatting Replace, context Context) (string, LookupResult) {
+	source.mutex.RLock()
+	defer source.mutex.RUnlock()
+	contexts := source.getContextData(context)
+	for _, foundContext := range contexts {
+		result, ok := t.findTranslation(text, number, hasNumber, formatting, context, foundContext.values)
+		if ok {
+			return result, ContextHit
+		}
+	}
+	result, ok := t.findTranslation(text, number, hasNumber, formatting, context, source.values)
+	switch {
+	case !ok:
+		return "", Miss
+	case len(contexts) > 0:
+		return result, Fallback
+	}
+	return result, Hit
+}
+
+// source returns Translator that keeps dictionary.
+func (t *Translator) source() *Translator {
+	if t.base != nil {
+		return t.base
+	}
+	return t
+}
+
+// dictionaries returns Translators that keep dictionary in search order.
+// Scoped translator searches its namespace and then the common namespace.
+func (t *Translator) dictionaries() []*Translator {
+	if t.common != nil {
+		return []*Translator{t.source(), t.common}
+	}
+	return []*Translator{t.source()}
+}
+
+func (t *Translator) filterText(text string) string {
+	if t.filter == nil {
+		return text
+	}
+	return t.filter(text)
+}
+
+// getContextData returns all contexts that match the passed context.
+// More specific contexts (that have more matching conditions) come first.
+// Contexts that have same specificity keep registered order.
+func (t *Translator) getContextData(context Context) []*contextEntry {
+	var result []*contextEntry
 	for _, definedContext := range t.contexts {
 		equal := true
 		for key, value := range definedContext.matches {
@@ -151,37 +231,57 @@ func (t *Translator) getContextData(context Context) (*contextEntry, bool) {
 			}
 		}
 		if equal {
-			return definedContext, true
+			result = append(result, definedContext)
 		}
 	}
-	return nil, false
+	sort.SliceStable(result, func(i, j int) bool {
+		return len(result[i].matches) > len(result[j].matches)
+	})
+	return result
 }
 
-func (t *Translator) findTranslation(text string, number int64, hasNumber bool, formatting Replace, values map[string]*translation) (string, bool) {
-	value := values[text]
+func (t *Translator) findTranslation(text string, number int64, hasNumber bool, formatting Replace, context Context, values map[string]*translation) (string, bool) {
+	value := selectTranslation(values[text], formatting, context)
 	if value == nil {
 		return "", false
 	}
 	if !hasNumber && len(value.pluralisations) == 0 {
-		return applyFormatting(value.translation, formatting), true
+		return applyFormatting(t.filterText(value.translation), formatting, t.isolate()), true
 	} else if hasNumber && len(value.pluralisations) != 0 {
 		for _, pluralisation := range value.pluralisations {
 			if pluralisation.min <= number && number <= pluralisation.max {
-				return applyFormattingWithNumber(pluralisation.translation, number, formatting), true
+				return applyFormattingWithNumber(t.filterText(pluralisation.translation), number, formatting, t.isolate()), true
 			}
 		}
 	}
 	return "", false
 }
 
+// selectTranslation resolves select forms. Each selector is looked up in Context and then in Replace,
+// and "other" case is used if no case matches. It returns nil if neither is found.
+func selectTranslation(value *translation, formatting Replace, context Context) *translation {
+	for value != nil && value.selector != nil {
+		cases := value.selector.cases
+		next := cases[otherCase]
+		if selected, ok := context[value.selector.name]; ok && cases[selected] != nil {
+			next = cases[selected]
+		} else if selected, ok := formatting[value.selector.name]; ok && cases[fmt.Sprint(selected)] != nil {
+			next = cases[fmt.Sprint(selected)]
+		}
+		value = next
+	}
+	return value
+}
+
 func (t *Translator) useOriginalText(text string, number int64, hasNumber bool, formatting Replace) string {
+	text = t.filterText(text)
 	if hasNumber {
-		return applyFormattingWithNumber(text, number, formatting)
+		return applyFormattingWithNumber(text, number, formatting, t.isolate())
 	}
-	return applyFormatting(text, formatting)
+	return applyFormatting(text, formatting, t.isolate())
 }
 
-func applyFormattingWithNumber(text string, num int64, format Replace) string {
+func applyFormattingWithNumber(text string, num int64, format Replace, isolate bool) string {
 	replaceMap := make([]string, len(format)*2+4)
 	replaceMap[0] = "%n"
 	replaceMap[1] = strconv.FormatInt(num, 10)
@@ -190,19 +290,19 @@ func applyFormattingWithNumber(text string, num int64, format Replace) string {
 	i := 2
 	for key, value := range format {
 		replaceMap[i*2] = "%{" + key + "}"
-		replaceMap[i*2+1] = fmt.Sprintf("%v", value)
+		replaceMap[i*2+1] = formatValue(value, isolate)
 		i++
 	}
 	replacer := strings.NewReplacer(replaceMap...)
 	return replacer.Replace(text)
 }
 
-func applyFormatting(text string, format Replace) string {
+func applyFormatting(text string, format Replace, isolate bool) string {
 	replaceMap := make([]string, len(format)*2)
 	i := 0
 	for key, value := range format {
 		replaceMap[i*2] = "%{" + key + "}"
-		replaceMap[i*2+1] = fmt.Sprintf("%v", value)
+		replaceMap[i*2+1] = formatValue(value, isolate)
 		i++
 	}
 	replacer := strings.NewReplacer(replaceMap...)
@@ -223,34 +323,50 @@ func convertNumber(value interface{}, defaultValue int64) (int64, bool) {
 type tmpContext struct {
 	Matches map[string]string      `json:"matches"`
 	Values  map[string]interface{} `json:"values"`
+	// Fuzzy keeps keys in this context that have draft translations.
+	Fuzzy []string `json:"fuzzy"`
 }
 
 type tmpLoader struct {
 	Values   map[string]interface{} `json:"values"`
 	Contexts []tmpContext           `json:"contexts"`
+	HTML     []string               `json:"html"`
+	// Fuzzy keeps keys in root values that have draft translations. They are not loaded like gettext's fuzzy entries.
+	// Contexts and select forms have their own fuzzy lists.
+	Fuzzy []string `json:"fuzzy"`
+	// Namespaces keeps dictionaries of namespaces. They can't be nested.
+	Namespaces map[string]*tmpLoader `json:"namespaces"`
 }
 
 func parseValue(context string, values map[string]*translation, key string, value interface{}) error {
+	entry, err := parseTranslation(context, key, value)
+	if err != nil {
+		return err
+	}
+	values[key] = entry
+	return nil
+}
+
+func parseTranslation(context string, key string, value interface{}) (*translation, error) {
 	switch v := value.(type) {
 	case string:
-		values[key] = &translation{translation: v}
+		return &translation{translation: v}, nil
 	case []interface{}:
 		entry := &translation{}
-		values[key] = entry
 		for _, pluralisation := range v {
 			pluralisationSpec, ok := pluralisation.([]interface{})
 			if ok && len(pluralisationSpec) == 3 {
 				min, ok := convertNumber(pluralisationSpec[0], math.MinInt64)
 				if !ok {
-					return errors.Errorf("First value of key '%s' at %s should be int, but '%v'", key, context, pluralisationSpec[0])
+					return nil, errors.Errorf("First value of key '%s' at %s should be int, but '%v'", key, context, pluralisationSpec[0])
 				}
 				max, ok := convertNumber(pluralisationSpec[1], math.MaxInt64)
 				if !ok {
-					return errors.Errorf("Second value of key '%s' at %s should be int, but '%v'", key, context, pluralisationSpec[1])
+					return nil, errors.Errorf("Second value of key '%s' at %s should be int, but '%v'", key, context, pluralisationSpec[1])
 				}
 				translationWord, ok := pluralisationSpec[2].(string)
 				if !ok {
-					return errors.Errorf("Third value of key '%s' at %s should be string, but '%v'", key, context, pluralisationSpec[2])
+					return nil, errors.Errorf("Third value of key '%s' at %s should be string, but '%v'", key, context, pluralisationSpec[2])
 				}
 				entry.pluralisations = append(entry.pluralisations, &pluralisationEntry{
 					min:         min,
@@ -259,10 +375,41 @@ func parseValue(context string, values map[string]*translation, key string, valu
 				})
 			}
 		}
-	default:
-		return errors.Errorf("value of key '%s' at %s should be string or pluralisation array, but '%v'", key, context, value)
+		return entry, nil
+	case map[string]interface{}:
+		name, ok := v["select"].(string)
+		if !ok || name == "" {
+			return nil, errors.Errorf("select form of key '%s' at %s should have selector name in \"select\", but '%v'", key, context, v["select"])
+		}
+		cases, ok := v["cases"].(map[string]interface{})
+		if !ok || len(cases) == 0 {
+			return nil, errors.Errorf("select form of key '%s' at %s should have \"cases\" object, but '%v'", key, context, v["cases"])
+		}
+		fuzzy, _ := v["fuzzy"].([]interface{})
+		selector := &selectorEntry{name: name, cases: make(map[string]*translation, len(cases))}
+		for caseName, caseValue := range cases {
+			if containsValue(fuzzy, caseName) {
+				// draft translation of the case is not used like fuzzy keys
+				continue
+			}
+			entry, err := parseTranslation(fmt.Sprintf("%s (%s=%s)", context, name, caseName), key, caseValue)
+			if err != nil {
+				return nil, err
+			}
+			selector.cases[caseName] = entry
+		}
+		return &translation{selector: selector}, nil
 	}
-	return nil
+	return nil, errors.Errorf("value of key '%s' at %s should be string, pluralisation array or select form, but '%v'", key, context, value)
+}
+
+func containsValue(values []interface{}, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 func (t *Translator) add(reader io.Reader) error {
@@ -274,8 +421,39 @@ func (t *Translator) add(reader io.Reader) error {
 	if err != nil {
 		return errors.Wrap(err, "json parse error")
 	}
+	t = t.source()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	err = t.load(loader)
+	if err != nil {
+		return err
+	}
+	for name, namespaceLoader := range loader.Namespaces {
+		if len(namespaceLoader.Namespaces) > 0 {
+			return errors.Errorf("namespace '%s' has nested namespaces", name)
+		}
+		namespace := t.namespaceDictionary(name)
+		namespace.mutex.Lock()
+		err = namespace.load(namespaceLoader)
+		namespace.mutex.Unlock()
+		if err != nil {
+			return errors.Wrapf(err, "namespace '%s'", name)
+		}
+	}
+	return nil
+}
+
+// load stores parsed JSON into the dictionary. Caller should lock the mutex.
+func (t *Translator) load(loader *tmpLoader) error {
+	fuzzy := make(map[string]bool, len(loader.Fuzzy))
+	for _, key := range loader.Fuzzy {
+		fuzzy[key] = true
+	}
 	for key, value := range loader.Values {
-		err = parseValue("root values", t.values, key, value)
+		if fuzzy[key] {
+			continue
+		}
+		err := parseValue("root values", t.values, key, value)
 		if err != nil {
 			return err
 		}
@@ -288,47 +466,94 @@ func (t *Translator) add(reader io.Reader) error {
 		for key, value := range contextSrc.Matches {
 			context.matches[key] = value
 		}
+		contextFuzzy := make(map[string]bool, len(contextSrc.Fuzzy))
+		for _, key := range contextSrc.Fuzzy {
+			contextFuzzy[key] = true
+		}
 		for key, value := range contextSrc.Values {
-			err = parseValue(fmt.Sprintf("context[%d]", i), context.values, key, value)
+			if contextFuzzy[key] {
+				continue
+			}
+			err := parseValue(fmt.Sprintf("context[%d]", i), context.values, key, value)
 			if err != nil {
 				return err
 			}
 		}
 		t.contexts = append(t.contexts, context)
 	}
-
+	for _, key := range loader.HTML {
+		if t.htmlKeys == nil {
+			t.htmlKeys = make(map[string]bool)
+		}
+		t.htmlKeys[key] = true
+	}
+	t.modified = time.Now()
 	return nil
 }
 
+/*
+AddWord method adds key and value pair to root values of the dictionary.
+It is good for adding long text like email/html templates.
+*/
 func (t *Translator) AddWord(key, value string) {
-	parseValue("root values", t.values, key, value)
+	t = t.source()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.values[key] = &translation{translation: value}
+	t.modified = time.Now()
 }
 
 /*
 Create returns new Translator instance.
 
 If JSON format is invalid, it returns error.
+
+If tag is specified as 2nd parameter, the translator uses it as its language.
+It is used for selecting text direction (see Direction method).
+*/
+func Create(reader io.Reader, tag ...language.Tag) (*Translator, error) {
+	return CreateInNamespace("", reader, tag...)
+}
+
+/*
+CreateInNamespace returns new Translator instance that has the dictionary in the namespace.
+It is similar to Create, but the dictionary is not registered into the common namespace (see AddToNamespace).
 */
-func Create(reader io.Reader) (*Translator, error) {
+func CreateInNamespace(namespace string, reader io.Reader, tag ...language.Tag) (*Translator, error) {
+	selected, hasTag, err := singleTag(tag)
+	if err != nil {
+		return nil, err
+	}
 	result := &Translator{
 		values:        make(map[string]*translation),
 		globalContext: make(Context),
 	}
-	err := result.add(reader)
+	if hasTag {
+		result.setTag(selected)
+	}
+	err = result.addToNamespace(reader, namespace)
 	if err != nil {
 		return nil, err
 	}
 	return result, err
 }
 
+// addToNamespace adds dictionary into the namespace of t.
+func (t *Translator) addToNamespace(reader io.Reader, namespace string) error {
+	if namespace == "" {
+		return t.add(reader)
+	}
+	return t.Namespace(namespace).add(reader)
+}
+
 /*
 MustCreate returns new Translator instance.
 
 If JSON format is invalid, it makes application panic.
 It is good for static initialization.
 */
-func MustCreate(reader io.Reader) *Translator {
-	t, err := Create(reader)
+func MustCreate(reader io.Reader, tag ...language.Tag) *Translator {
+	t, err := Create(reader, tag...)
 	if err != nil {
 		panic(err)
 	}
@@ -341,8 +566,8 @@ It is similar to Create, but it accepts string instead of []byte.
 
 If JSON format is invalid, it returns error.
 */
-func CreateFromString(json string) (*Translator, error) {
-	return Create(strings.NewReader(json))
+func CreateFromString(json string, tag ...language.Tag) (*Translator, error) {
+	return Create(strings.NewReader(json), tag...)
 }
 
 /*
@@ -352,8 +577,8 @@ It is similar to MustCreate, but it accepts string instead of []byte.
 If JSON format is invalid, it makes application panic.
 It is good for static initialization.
 */
-func MustCreateFromString(json string) *Translator {
-	t, err := Create(strings.NewReader(json))
+func MustCreateFromString(json string, tag ...language.Tag) *Translator {
+	t, err := Create(strings.NewReader(json), tag...)
 	if err != nil {
 		panic(err)
 	}
@@ -381,31 +606,70 @@ If JSON format is invalid, it returns error.
 
 If tag is specified as 2nd parameter, it registers dictionary to specified language translator.
 You can access the registered translator with Select, SelectTranslator
-SelectTranslatorWithRequest, SelectWithRequest functions
+SelectTranslatorWithRequest, SelectWithRequest functions.
 */
 func Add(reader io.Reader, tag ...language.Tag) error {
-	lock.Lock()
+	return AddToNamespace("", reader, tag...)
+}
+
+/*
+AddToNamespace registers dictionary into the namespace of default Translator instance
+(or specified language translator if tag is specified).
+
+Large applications can split dictionaries by module to avoid collisions of short keys like "Save":
+
+	i18n4v.AddToNamespace("billing", reader, language.Japanese)
+
+Namespaced dictionaries are accessed via Translator.Namespace method.
+Dictionaries registered by Add are in the common namespace (empty name).
+
+In JSON, namespaces can be written in one file too:
+
+	{
+	    "values": {"Save": "保存"},
+	    "namespaces": {
+	        "billing": {"values": {"Save": "支払う"}}
+	    }
+	}
+*/
+func AddToNamespace(namespace string, reader io.Reader, tag ...language.Tag) error {
+	selected, hasTag, err := singleTag(tag)
+	if err != nil {
+		return err
+	}
+	if !hasTag {
+		lock.Lock()
+		defer lock.Unlock()
+		return defaultTranslator.addToNamespace(reader, namespace)
+	}
+	for {
+		// locale registered by AddLoader should be loaded before adding words
+		if _, err := registeredTranslator(selected); err != nil {
+			return err
+		}
+		lock.Lock()
+		if _, ok := translators[selected]; ok || loaders[selected] == nil {
+			break
+		}
+		// unloaded by UnloadUnused after loading. Load it again not to replace the loader's dictionary.
+		lock.Unlock()
+	}
 	defer lock.Unlock()
 
-	switch len(tag) {
-	case 0:
-		return defaultTranslator.add(reader)
-	case 1:
-		translator, ok := translators[tag[0]]
-		if !ok {
-			translator, err := Create(reader)
-			if err != nil {
-				return err
-			}
-			translators[tag[0]] = translator
-			languages = append(languages, tag[0])
+	translator, ok := translators[selected]
+	if !ok {
+		translator, err := CreateInNamespace(namespace, reader, selected)
+		if err != nil {
+			return err
+		}
+		translators[selected] = translator
+		if _, ok := loaders[selected]; !ok {
+			languages = append(languages, selected)
 			matcher = nil
-			return nil
 		}
-		return translator.add(reader)
-	default:
-		return errors.New("Only one tag is acceptable")
+		return nil
 	}
+	return translator.addToNamespace(reader, namespace)
 }
 
 /*
@@ -415,8 +679,6 @@ If JSON format is invalid, it makes application panic.
 It is good for static initialization.
 
 If tag is specified as 2nd parameter, it registers dictionary to specified language translator.
-You can access the registered translator with Select, SelectTranslator
-SelectTranslatorWithRequest, SelectWithRequest functions
 */
 func MustAdd(reader io.Reader, tag ...language.Tag) {
 	err := Add(reader, tag...)
@@ -425,6 +687,18 @@ func MustAdd(reader io.Reader, tag ...language.Tag) {
 	}
 }
 
+/*
+MustAddToNamespace registers dictionary into the namespace like AddToNamespace.
+
+If JSON format is invalid, it makes application panic.
+*/
+func MustAddToNamespace(namespace string, reader io.Reader, tag ...language.Tag) {
+	err := AddToNamespace(namespace, reader, tag...)
+	if err != nil {
+		panic(err)
+	}
+}
+
 /*
 AddFromString registers dictionary to default Translator instance.
 It is similar to Add, but it accepts string instead of []byte.
@@ -432,13 +706,8 @@ It is similar to Add, but it accepts string instead of []byte.
 If JSON format is invalid, it returns error.
 
 If tag is specified as 2nd parameter, it registers dictionary to specified language translator.
-You can access the registered translator with Select, SelectTranslator
-SelectTranslatorWithRequest, SelectWithRequest functions
 */
 func AddFromString(json string, tag ...language.Tag) error {
-	lock.Lock()
-	defer lock.Unlock()
-
 	return Add(strings.NewReader(json), tag...)
 }
 
@@ -450,8 +719,6 @@ If JSON format is invalid, it makes application panic.
 It is good for static initialization.
 
 If tag is specified as 2nd parameter, it registers dictionary to specified language translator.
-You can access the registered translator with Select, SelectTranslator
-SelectTranslatorWithRequest, SelectWithRequest functions
 */
 func MustAddFromString(json string, tag ...language.Tag) {
 	MustAdd(strings.NewReader(json), tag...)
@@ -466,8 +733,11 @@ func AddWord(key, value string, tag ...language.Tag) error {
 	case 0:
 		defaultTranslator.AddWord(key, value)
 	case 1:
-		translator, ok := translators[tag[0]]
-		if !ok {
+		translator, err := registeredTranslator(tag[0])
+		if err != nil {
+			return err
+		}
+		if translator == nil {
 			return errors.New("Specified tag is not registered")
 		}
 		translator.AddWord(key, value)
@@ -481,9 +751,17 @@ func AddWord(key, value string, tag ...language.Tag) error {
 Reset clears default Translator instance.
 */
 func Reset() {
+	defaultTranslator.mutex.Lock()
 	defaultTranslator.values = make(map[string]*translation)
+	defaultTranslator.contexts = nil
+	defaultTranslator.htmlKeys = nil
 	defaultTranslator.globalContext = make(Context)
+	defaultTranslator.namespaces = nil
+	defaultTranslator.mutex.Unlock()
+	lock.Lock()
+	defer lock.Unlock()
 	translators = make(map[language.Tag]*Translator)
+	loaders = make(map[language.Tag]*lazyLocale)
 	languages = []language.Tag{}
 	matcher = nil
 }
@@ -1,6 +1,7 @@
 package i18n4v
 
 import (
+	"golang.org/x/text/language"
 	"testing"
 )
 
@@ -224,3 +225,180 @@ func TestDefaultTranslatorWithFullSpec(t *testing.T) {
 		t.Errorf("translated word should be 'Janeは彼女のHen's Nightアルバムに写真4枚をアップロードしました', but %s", translate2)
 	}
 }
+
+func TestReset(t *testing.T) {
+	MustAddFromString(`{
+        "values": {"Hello": "こんにちは"},
+        "contexts": [
+            {"matches": {"gender": "female"}, "values": {"Welcome": "ようこそ(女性)"}}
+        ],
+        "html": ["Notice"],
+        "namespaces": {
+            "billing": {"values": {"Save": "支払う"}}
+        }
+    }`)
+	MustAddFromString(`{"values": {"Hello": "Hello"}}`, language.English)
+	Reset()
+	if r := Translate("Hello"); r != "Hello" {
+		t.Errorf("Reset should clear values, but %s", r)
+	}
+	if r := Translate("Welcome", Replace{}, Context{"gender": "female"}); r != "Welcome" {
+		t.Errorf("Reset should clear contexts, but %s", r)
+	}
+	if defaultTranslator.isHTMLKey("Notice") {
+		t.Error("Reset should clear HTML keys")
+	}
+	if names := defaultTranslator.Namespaces(); len(names) != 0 {
+		t.Errorf("Reset should clear namespaces, but %v", names)
+	}
+	if tags := Languages(); len(tags) != 0 {
+		t.Errorf("Reset should clear registered languages, but %v", tags)
+	}
+}
+
+func TestContextFallback(t *testing.T) {
+	ja := MustCreateFromString(`{
+        "values": {
+            "Hello": "こんにちは",
+            "Goodbye": "さようなら",
+            "Thank you": "ありがとう"
+        },
+        "contexts": [
+            {
+                "matches": {"gender": "female"},
+                "values": {
+                    "Hello": "こんにちは(女性)",
+                    "Goodbye": "さようなら(女性)"
+                }
+            },
+            {
+                "matches": {"gender": "female", "formality": "polite"},
+                "values": {
+                    "Hello": "ごきげんよう"
+                }
+            }
+        ]
+    }`)
+	context := Context{"gender": "female", "formality": "polite"}
+	if r := ja.Translate("Hello", Replace{}, context); r != "ごきげんよう" {
+		t.Errorf("It should use the most specific context, but %s", r)
+	}
+	if r := ja.Translate("Goodbye", Replace{}, context); r != "さようなら(女性)" {
+		t.Errorf("It should fall back into less specific context, but %s", r)
+	}
+	if r := ja.Translate("Thank you", Replace{}, context); r != "ありがとう" {
+		t.Errorf("It should fall back into root values, but %s", r)
+	}
+}
+
+func TestFuzzyEntries(t *testing.T) {
+	ja := MustCreateFromString(`{
+        "values": {
+            "Delete file": "ファイルを削除",
+            "Delete files": "ファイルを削除",
+            "%{name} replied": {
+                "select": "gender",
+                "cases": {
+                    "female": "%{name}さん(女性)が返信しました",
+                    "other": "%{name}さんが返信しました"
+                },
+                "fuzzy": ["female"]
+            }
+        },
+        "contexts": [
+            {
+                "matches": {"gender": "female"},
+                "values": {
+                    "Delete file": "ファイルを削除(女性)",
+                    "Delete files": "ファイルを全て削除(女性)"
+                },
+                "fuzzy": ["Delete file"]
+            }
+        ],
+        "fuzzy": ["Delete files"]
+    }`)
+	if r := ja.Translate("Delete file"); r != "ファイルを削除" {
+		t.Errorf("It should use translation, but %s", r)
+	}
+	if r := ja.Translate("Delete files"); r != "Delete files" {
+		t.Errorf("It should not use fuzzy translation, but %s", r)
+	}
+	if r := ja.Translate("Delete files", Replace{}, Context{"gender": "female"}); r != "ファイルを全て削除(女性)" {
+		t.Errorf("Fuzzy key in root values should not hide reviewed translation in context, but %s", r)
+	}
+	if r := ja.Translate("Delete file", Replace{}, Context{"gender": "female"}); r != "ファイルを削除" {
+		t.Errorf("It should not use fuzzy translation in context, but %s", r)
+	}
+	if r := ja.Translate("%{name} replied", Replace{"name": "花子", "gender": "female"}); r != "花子さんが返信しました" {
+		t.Errorf("It should not use fuzzy case of select form, but %s", r)
+	}
+}
+
+func TestIgnoreMeta(t *testing.T) {
+	ja, err := CreateFromString(`{
+        "values": {
+            "Pay": "支払う"
+        },
+        "meta": {
+            "Pay": {"refs": ["app/checkout.go:12"], "comment": "shown on the checkout button"}
+        }
+    }`)
+	if err != nil {
+		t.Fatalf("It should accept meta section: %v", err)
+	}
+	if r := ja.Translate("Pay"); r != "支払う" {
+		t.Errorf("It should use translation, but %s", r)
+	}
+	if keys := ja.Keys(); len(keys) != 1 {
+		t.Errorf("Meta should not be loaded as translations, but %v", keys)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	de := MustCreateFromString(`{
+        "values": {
+            "%{name} updated their profile": {
+                "select": "gender",
+                "cases": {
+                    "male": "%{name} hat sein Profil aktualisiert",
+                    "female": "%{name} hat ihr Profil aktualisiert",
+                    "other": "%{name} hat das Profil aktualisiert"
+                }
+            },
+            "%n new messages for %{name}": {
+                "select": "case",
+                "cases": {
+                    "dative": {
+                        "select": "gender",
+                        "cases": {
+                            "female": [[1, 1, "%n neue Nachricht für die Kundin"], [2, null, "%n neue Nachrichten für die Kundin"]],
+                            "other": [[1, 1, "%n neue Nachricht für den Kunden"], [2, null, "%n neue Nachrichten für den Kunden"]]
+                        }
+                    }
+                }
+            }
+        }
+    }`)
+	if result := de.Translate("%{name} updated their profile", Replace{"name": "Anna"}, Context{"gender": "female"}); result != "Anna hat ihr Profil aktualisiert" {
+		t.Errorf("It should select the case from Context, but %s", result)
+	}
+	if result := de.Translate("%{name} updated their profile", Replace{"name": "Jan", "gender": "male"}); result != "Jan hat sein Profil aktualisiert" {
+		t.Errorf("It should select the case from Replace, but %s", result)
+	}
+	if result := de.Translate("%{name} updated their profile", Replace{"name": "Kim"}); result != "Kim hat das Profil aktualisiert" {
+		t.Errorf("It should use other case if the selector is not passed, but %s", result)
+	}
+	if result := de.Translate("%n new messages for %{name}", 2, Replace{"case": "dative"}, Context{"gender": "female"}); result != "2 neue Nachrichten für die Kundin" {
+		t.Errorf("It should resolve nested select forms and pluralisation, but %s", result)
+	}
+	if result := de.Translate("%n new messages for %{name}", 1, Replace{"case": "dative", "gender": "male"}); result != "1 neue Nachricht für den Kunden" {
+		t.Errorf("It should use other case of nested select form, but %s", result)
+	}
+	if result := de.Translate("%n new messages for %{name}", 1, Replace{"name": "Anna", "case": "accusative"}); result != "1 new messages for Anna" {
+		t.Errorf("It should pass through key if no case matches, but %s", result)
+	}
+	_, err := CreateFromString(`{"values": {"Hello": {"select": "gender"}}}`)
+	if err == nil {
+		t.Error("It should return error if select form doesn't have cases")
+	}
+}
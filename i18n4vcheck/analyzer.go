@@ -0,0 +1,354 @@
+/*
+Package i18n4vcheck provides an analyzer that finds misuse of i18n4v's Translate.
+
+Translate accepts optional parameters in fixed order (default text, count, Replace, Context),
+and wrong types or wrong order make application panic or make the parameters ignored at runtime.
+This analyzer reports them at build time in calls of Translate function, Translate and TranslateHTML methods
+of Translator and TranslatorFunction values (like the result of Select):
+
+* Translation keys that are not constant strings (the extractor can't find them)
+* Parameters that Translate doesn't accept like int64 or map[string]string
+* Parameters in wrong order, and Context that is ignored because Replace is not passed before it
+* Placeholders (%{name}) in key, default text or translations that are missing in Replace
+* Replace entries that are not used in key, default text or translations
+
+Translations are read from JSON dictionaries that are passed by -dictionary flag (comma separated).
+Translations in a namespace are checked only for calls via the translator returned by Namespace method
+with a constant name (directly or via a variable). Other calls use translations of the common namespace.
+
+It can be used with go vet and gopls:
+
+	go install github.com/shibukawa/i18n4v/i18n4vcheck/cmd/i18n4vcheck
+	go vet -vettool=$(which i18n4vcheck) ./...
+*/
+package i18n4vcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const packagePath = "github.com/shibukawa/i18n4v"
+
+/*
+Analyzer reports misuse of Translate and TranslateHTML.
+*/
+var Analyzer = &analysis.Analyzer{
+	Name:     "i18n4v",
+	Doc:      "check arguments of i18n4v's Translate\n\nIt reports non-constant keys, wrong arguments, and placeholders that don't match Replace.",
+	URL:      "https://github.com/shibukawa/i18n4v",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var dictionaryFlag string
+
+func init() {
+	Analyzer.Flags.StringVar(&dictionaryFlag, "dictionary", "", "comma separated JSON dictionaries to read translations from")
+}
+
+// argument kinds in the order that Translate accepts
+const (
+	defaultTextArgument = iota
+	countArgument
+	replaceArgument
+	contextArgument
+	unknownArgument
+)
+
+var argumentNames = []string{"default text", "count", "i18n4v.Replace", "i18n4v.Context"}
+
+var placeholderPattern = regexp.MustCompile(`%\{([^}]+)\}`)
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	dictionaries, err := loadDictionaries(dictionaryFlag)
+	if err != nil {
+		return nil, err
+	}
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	namespaces := findNamespaces(pass, inspect)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		if !isTranslate(pass, call) || len(call.Args) == 0 {
+			return
+		}
+		checkCall(pass, call, translatorNamespace(pass, call, namespaces), dictionaries)
+	})
+	return nil, nil
+}
+
+// namespaceName returns the name if the expression is a call of Namespace method with a constant name.
+func namespaceName(pass *analysis.Pass, expr ast.Expr) (string, bool) {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return "", false
+	}
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Name() != "Namespace" || fn.Pkg() == nil || fn.Pkg().Path() != packagePath {
+		return "", false
+	}
+	value := pass.TypesInfo.Types[call.Args[0]].Value
+	if value == nil || value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(value), true
+}
+
+// findNamespaces returns variables that keep translators of namespaces.
+// Variables that are assigned translators of different namespaces (or other values) are not included.
+func findNamespaces(pass *analysis.Pass, inspect *inspector.Inspector) map[types.Object]string {
+	result := make(map[types.Object]string)
+	unknown := make(map[types.Object]bool)
+	assign := func(lhs *ast.Ident, rhs ast.Expr) {
+		obj := pass.TypesInfo.ObjectOf(lhs)
+		if obj == nil || unknown[obj] {
+			return
+		}
+		name, ok := namespaceName(pass, rhs)
+		if previous, found := result[obj]; !ok || (found && previous != name) {
+			delete(result, obj)
+			unknown[obj] = true
+			return
+		}
+		result[obj] = name
+	}
+	inspect.Preorder([]ast.Node{(*ast.AssignStmt)(nil), (*ast.ValueSpec)(nil)}, func(node ast.Node) {
+		switch n := node.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				return
+			}
+			for i, lhs := range n.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					assign(ident, n.Rhs[i])
+				}
+			}
+		case *ast.ValueSpec:
+			if len(n.Names) != len(n.Values) {
+				return
+			}
+			for i, ident := range n.Names {
+				assign(ident, n.Values[i])
+			}
+		}
+	})
+	return result
+}
+
+// translatorNamespace returns the namespace of the translator that the call uses.
+// It returns empty string for Translate function and translators whose namespace is not known.
+func translatorNamespace(pass *analysis.Pass, call *ast.CallExpr, namespaces map[types.Object]string) string {
+	selector, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	if name, ok := namespaceName(pass, selector.X); ok {
+		return name
+	}
+	if ident, ok := ast.Unparen(selector.X).(*ast.Ident); ok {
+		return namespaces[pass.TypesInfo.Uses[ident]]
+	}
+	return ""
+}
+
+// isTranslate returns true if the call is Translate function, Translate/TranslateHTML method of Translator
+// or TranslatorFunction value (like the result of i18n4v.Select).
+func isTranslate(pass *analysis.Pass, call *ast.CallExpr) bool {
+	if t := pass.TypesInfo.TypeOf(call.Fun); t != nil && isNamed(t, "TranslatorFunction") {
+		return true
+	}
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != packagePath {
+		return false
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return fn.Name() == "Translate"
+	}
+	return (fn.Name() == "Translate" || fn.Name() == "TranslateHTML") && isNamed(recv.Type(), "Translator")
+}
+
+// isNamed returns true if the type is the named type of i18n4v package (or pointer to it).
+func isNamed(t types.Type, name string) bool {
+	if pointer, ok := t.(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == packagePath && obj.Name() == name
+}
+
+func argumentKind(t types.Type) int {
+	switch {
+	case types.Identical(t, types.Typ[types.String]), types.Identical(t, types.Typ[types.UntypedString]):
+		return defaultTextArgument
+	case types.Identical(t, types.Typ[types.Int]), types.Identical(t, types.Typ[types.UntypedInt]):
+		return countArgument
+	case isNamed(t, "Replace"):
+		return replaceArgument
+	case isNamed(t, "Context"):
+		return contextArgument
+	}
+	return unknownArgument
+}
+
+// argumentHint returns suggestion for the type that Translate doesn't accept.
+func argumentHint(t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsInteger != 0:
+			return "; convert it to int"
+		case u.Info()&types.IsString != 0:
+			return "; convert it to string"
+		}
+	case *types.Map:
+		if types.Identical(u.Elem(), types.Typ[types.String]) {
+			return "; use i18n4v.Context"
+		}
+		return "; use i18n4v.Replace"
+	}
+	return ""
+}
+
+// calleeName returns the name of the translation function or variable that is used in messages.
+func calleeName(pass *analysis.Pass, call *ast.CallExpr) string {
+	if callee := typeutil.Callee(pass.TypesInfo, call); callee != nil {
+		return callee.Name()
+	}
+	return "TranslatorFunction"
+}
+
+func checkCall(pass *analysis.Pass, call *ast.CallExpr, namespace string, dictionaries []*dictionary) {
+	name := calleeName(pass, call)
+	keyValue := pass.TypesInfo.Types[call.Args[0]].Value
+	if keyValue == nil || keyValue.Kind() != constant.String {
+		pass.Reportf(call.Args[0].Pos(), "key of %s should be a constant string", name)
+	}
+	// Translate(key, args...) passes parameters that can't be checked
+	if call.Ellipsis.IsValid() {
+		return
+	}
+	args := call.Args[1:]
+	last := -1
+	var defaultText ast.Expr
+	var replace ast.Expr
+	for i, arg := range args {
+		t := pass.TypesInfo.TypeOf(arg)
+		if t == nil {
+			return
+		}
+		kind := argumentKind(t)
+		switch {
+		case kind == unknownArgument:
+			pass.Reportf(arg.Pos(), "%s doesn't accept %s as parameter%s", name, t.String(), argumentHint(t))
+			return
+		case kind <= last:
+			pass.Reportf(arg.Pos(), "%s should be passed before %s in %s", argumentNames[kind], argumentNames[last], name)
+			return
+		case kind == contextArgument && last == -1:
+			pass.Reportf(arg.Pos(), "i18n4v.Context can't be the first parameter of %s; pass i18n4v.Replace{} before it", name)
+			return
+		case kind == contextArgument && last != replaceArgument:
+			pass.Reportf(arg.Pos(), "i18n4v.Context is ignored by %s unless i18n4v.Replace is passed before it", name)
+			return
+		}
+		switch kind {
+		case defaultTextArgument:
+			defaultText = args[i]
+		case replaceArgument:
+			replace = args[i]
+		}
+		last = kind
+	}
+	if keyValue == nil || keyValue.Kind() != constant.String {
+		return
+	}
+	checkPlaceholders(pass, call, namespace, constant.StringVal(keyValue), defaultText, replace, dictionaries)
+}
+
+// checkPlaceholders compares placeholders in key, default text and translations with entries of Replace literal.
+// Translations are searched in the namespace of the translator and the common namespace.
+func checkPlaceholders(pass *analysis.Pass, call *ast.CallExpr, namespace, key string, defaultText, replace ast.Expr, dictionaries []*dictionary) {
+	texts := []string{key}
+	if defaultText != nil {
+		value := pass.TypesInfo.Types[defaultText].Value
+		if value == nil || value.Kind() != constant.String {
+			// default text is not known
+			return
+		}
+		texts = append(texts, constant.StringVal(value))
+	}
+	for _, dict := range dictionaries {
+		texts = append(texts, dict.translations(namespace, key)...)
+	}
+	used := make(map[string]bool)
+	for _, text := range texts {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+			used[match[1]] = true
+		}
+	}
+	// selectors of select forms can be passed via Replace, but they are not required
+	selectors := make(map[string]bool)
+	for _, dict := range dictionaries {
+		for _, name := range dict.selectors(namespace, key) {
+			selectors[name] = true
+		}
+	}
+	entries := make(map[string]bool)
+	var names []*ast.BasicLit
+	if replace != nil {
+		literal, ok := ast.Unparen(replace).(*ast.CompositeLit)
+		if !ok {
+			// Replace is not a literal. Entries are not known
+			return
+		}
+		for _, element := range literal.Elts {
+			keyValue, ok := element.(*ast.KeyValueExpr)
+			if !ok {
+				return
+			}
+			name, ok := keyValue.Key.(*ast.BasicLit)
+			if !ok {
+				return
+			}
+			value := pass.TypesInfo.Types[name].Value
+			if value == nil || value.Kind() != constant.String {
+				return
+			}
+			entries[constant.StringVal(value)] = true
+			names = append(names, name)
+		}
+	}
+	var missing []string
+	for name := range used {
+		if !entries[name] {
+			missing = append(missing, "%{"+name+"}")
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		pos := call.Args[0].Pos()
+		if replace != nil {
+			pos = replace.Pos()
+		}
+		pass.Reportf(pos, "placeholders of key '%s' are missing in i18n4v.Replace: %s", key, strings.Join(missing, ", "))
+	}
+	for _, name := range names {
+		value := constant.StringVal(pass.TypesInfo.Types[name].Value)
+		if !used[value] && !selectors[value] {
+			pass.Reportf(name.Pos(), "i18n4v.Replace entry '%s' is not used in key '%s' or its translations", value, key)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package i18n4vcheck
+
+import (
+	"golang.org/x/tools/go/analysis/analysistest"
+	"testing"
+)
+
+func TestAnalyzer(t *testing.T) {
+	if err := Analyzer.Flags.Set("dictionary", "testdata/ja.json"); err != nil {
+		t.Fatal(err)
+	}
+	defer Analyzer.Flags.Set("dictionary", "")
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
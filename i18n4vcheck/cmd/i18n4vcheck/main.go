@@ -0,0 +1,19 @@
+/*
+Command i18n4vcheck reports misuse of i18n4v's Translate.
+
+It runs standalone or as a vet tool:
+
+	i18n4vcheck ./...
+	i18n4vcheck -dictionary=locales/ja.json,locales/fr.json ./...
+	go vet -vettool=$(which i18n4vcheck) ./...
+*/
+package main
+
+import (
+	"github.com/shibukawa/i18n4v/i18n4vcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(i18n4vcheck.Analyzer)
+}
@@ -0,0 +1,133 @@
+package i18n4vcheck
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shibukawa/i18n4v"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dictionary is a translator that is read from a JSON dictionary.
+type dictionary struct {
+	translator *i18n4v.Translator
+}
+
+// entries returns all entries of the key in root values and contexts that the translator of the namespace can use.
+// Translators of namespaces search the namespace first and then the common namespace.
+func (d *dictionary) entries(namespace, key string) []*i18n4v.Entry {
+	scoped := d.translator.Namespace(namespace)
+	contexts := scoped.Contexts()
+	if namespace != "" {
+		contexts = append(contexts, d.translator.Contexts()...)
+	}
+	var result []*i18n4v.Entry
+	if entry, ok := scoped.Lookup(key); ok {
+		result = append(result, entry)
+	}
+	for _, context := range contexts {
+		if entry, ok := scoped.Lookup(key, context); ok {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// translations returns all translations of the key that the translator of the namespace can use.
+func (d *dictionary) translations(namespace, key string) []string {
+	var result []string
+	for _, entry := range d.entries(namespace, key) {
+		result = append(result, entryTexts(entry)...)
+	}
+	return result
+}
+
+// selectors returns names of select forms of the key. They can be passed via Replace.
+func (d *dictionary) selectors(namespace, key string) []string {
+	var result []string
+	for _, entry := range d.entries(namespace, key) {
+		result = append(result, entrySelectors(entry)...)
+	}
+	return result
+}
+
+// entryTexts returns all texts of the entry including pluralisations and select cases.
+func entryTexts(entry *i18n4v.Entry) []string {
+	var result []string
+	switch {
+	case entry.IsSelect():
+		for _, caseEntry := range entry.Cases {
+			result = append(result, entryTexts(caseEntry)...)
+		}
+	case entry.IsPlural():
+		for _, pluralisation := range entry.Pluralisations {
+			result = append(result, pluralisation.Translation)
+		}
+	default:
+		result = append(result, entry.Translation)
+	}
+	return result
+}
+
+// entrySelectors returns names of select forms in the entry including nested ones.
+func entrySelectors(entry *i18n4v.Entry) []string {
+	if !entry.IsSelect() {
+		return nil
+	}
+	result := []string{entry.Select}
+	for _, caseEntry := range entry.Cases {
+		result = append(result, entrySelectors(caseEntry)...)
+	}
+	return result
+}
+
+// cachedDictionary keeps modification time of the file to reload it when it is modified.
+type cachedDictionary struct {
+	dictionary *dictionary
+	modTime    time.Time
+}
+
+// dictionaries are cached while the analyzer process runs. The analyzer runs for each package,
+// and gopls keeps running while dictionaries are edited.
+var dictionaries = make(map[string]*cachedDictionary)
+var dictionaryLock sync.Mutex
+
+// loadDictionaries reads comma separated JSON dictionaries.
+func loadDictionaries(paths string) ([]*dictionary, error) {
+	if paths == "" {
+		return nil, nil
+	}
+	dictionaryLock.Lock()
+	defer dictionaryLock.Unlock()
+	var result []*dictionary
+	for _, path := range strings.Split(paths, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		cached, ok := dictionaries[path]
+		if !ok || !cached.modTime.Equal(info.ModTime()) {
+			file, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			translator, err := i18n4v.Create(file)
+			file.Close()
+			if err != nil {
+				return nil, errors.Wrapf(err, "can't read dictionary %s", path)
+			}
+			cached = &cachedDictionary{
+				dictionary: &dictionary{translator: translator},
+				modTime:    info.ModTime(),
+			}
+			dictionaries[path] = cached
+		}
+		result = append(result, cached.dictionary)
+	}
+	return result, nil
+}
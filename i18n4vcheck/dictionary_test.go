@@ -0,0 +1,44 @@
+package i18n4vcheck
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadDictionariesReloadsModifiedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "i18n4vcheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ja.json")
+	write := func(content string, modTime time.Time) {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	write(`{"values": {"Hello": "こんにちは"}}`, now.Add(-time.Minute))
+	dicts, err := loadDictionaries(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texts := dicts[0].translations("", "Hello"); !reflect.DeepEqual(texts, []string{"こんにちは"}) {
+		t.Errorf("It should read translations, but %v", texts)
+	}
+	write(`{"values": {"Hello": "こんにちは、%{name}さん"}}`, now)
+	dicts, err = loadDictionaries(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texts := dicts[0].translations("", "Hello"); !reflect.DeepEqual(texts, []string{"こんにちは、%{name}さん"}) {
+		t.Errorf("It should reload modified dictionary, but %v", texts)
+	}
+}
@@ -0,0 +1,67 @@
+package a
+
+import (
+	"github.com/shibukawa/i18n4v"
+)
+
+const saveKey = "Save"
+
+func keys(key string, t *i18n4v.Translator) {
+	i18n4v.Translate("Save")
+	i18n4v.Translate(saveKey)
+	i18n4v.Translate(key)           // want "key of Translate should be a constant string"
+	t.Translate("Open " + key)      // want "key of Translate should be a constant string"
+	t.TranslateHTML(key, "default") // want "key of TranslateHTML should be a constant string"
+}
+
+func arguments(count int, count64 int64, args []interface{}) {
+	i18n4v.Translate("Save", "Save", count, i18n4v.Replace{}, i18n4v.Context{"gender": "female"})
+	i18n4v.Translate("%n files", count)
+	i18n4v.Translate("%n files", 2)
+	i18n4v.Translate("Save", args...)
+	i18n4v.Translate("%n files", count64)                         // want `Translate doesn't accept int64 as parameter; convert it to int`
+	i18n4v.Translate("Hello", map[string]string{"a": "b"})        // want `Translate doesn't accept map\[string\]string as parameter; use i18n4v.Context`
+	i18n4v.Translate("%n files", count, "%n files")               // want "default text should be passed before count in Translate"
+	i18n4v.Translate("Hello", i18n4v.Replace{}, count)            // want `count should be passed before i18n4v.Replace in Translate`
+	i18n4v.Translate("Hello", i18n4v.Context{"a": "b"})           // want `i18n4v.Context can't be the first parameter of Translate`
+	i18n4v.Translate("%n files", count, i18n4v.Context{"a": "b"}) // want `i18n4v.Context is ignored by Translate unless i18n4v.Replace is passed before it`
+}
+
+func placeholders(name string, replace i18n4v.Replace) {
+	i18n4v.Translate("Hello %{name}", i18n4v.Replace{"name": name})
+	i18n4v.Translate("Hello %{name}", replace)
+	i18n4v.Translate("Hello %{name}")                              // want `placeholders of key 'Hello %{name}' are missing in i18n4v.Replace: %{name}`
+	i18n4v.Translate("Hello %{name}", i18n4v.Replace{"nam": name}) // want `placeholders of key 'Hello %{name}' are missing in i18n4v.Replace: %{name}` `i18n4v.Replace entry 'nam' is not used in key 'Hello %{name}' or its translations`
+	i18n4v.Translate("Welcome", "Welcome %{name}", i18n4v.Replace{"name": name})
+	i18n4v.Translate("Welcome back", i18n4v.Replace{"name": name}) // want `i18n4v.Replace entry 'name' is not used`
+	i18n4v.Translate("Welcome back")
+	i18n4v.Translate("%{name} replied", i18n4v.Replace{"name": name, "gender": "male"})
+	i18n4v.Translate("%{name} replied", i18n4v.Replace{"name": name, "mood": "happy"}) // want `i18n4v.Replace entry 'mood' is not used`
+}
+
+type service struct {
+	tr *i18n4v.Translator
+}
+
+func namespaces(name string, t *i18n4v.Translator, s *service) {
+	admin := t.Namespace("admin")
+	admin.Translate("Welcome back", i18n4v.Replace{"user": name})
+	admin.Translate("Welcome back") // want `placeholders of key 'Welcome back' are missing in i18n4v.Replace: %{user}`
+	t.Namespace("admin").Translate("Welcome back", i18n4v.Replace{"user": name})
+	t.Namespace("billing").Translate("Welcome back", i18n4v.Replace{"user": name}) // want `i18n4v.Replace entry 'user' is not used`
+	admin.Translate("%{name} replied", i18n4v.Replace{"name": name, "gender": "male"})
+	s.tr.Translate("Welcome back")
+	other := t.Namespace("admin")
+	other = t.Namespace("billing")
+	other.Translate("Welcome back")
+}
+
+func translatorFunctions(key, name string) {
+	tr := i18n4v.Select("ja")
+	tr("Save")
+	tr(key)                                        // want "key of tr should be a constant string"
+	tr("Hello %{name}")                            // want `placeholders of key 'Hello %{name}' are missing in i18n4v.Replace: %{name}`
+	i18n4v.Select("ja")("Hello", i18n4v.Context{}) // want `i18n4v.Context can't be the first parameter of TranslatorFunction`
+	var fn i18n4v.TranslatorFunction = i18n4v.Translate
+	fn("Welcome back", i18n4v.Replace{"name": name}) // want `i18n4v.Replace entry 'name' is not used`
+}
@@ -0,0 +1,29 @@
+package i18n4v
+
+type Replace map[string]interface{}
+
+type Context map[string]string
+
+type TranslatorFunction func(text string, args ...interface{}) string
+
+type Translator struct{}
+
+func (t *Translator) Translate(text string, args ...interface{}) string {
+	return text
+}
+
+func (t *Translator) Namespace(name string) *Translator {
+	return t
+}
+
+func (t *Translator) TranslateHTML(text string, args ...interface{}) string {
+	return text
+}
+
+func Translate(key string, args ...interface{}) string {
+	return key
+}
+
+func Select(lang string) TranslatorFunction {
+	return Translate
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// catalogEntry is a translation entry in flat form for PO and XLIFF files.
+type catalogEntry struct {
+	namespace string
+	matches   map[string]string
+	key       string
+	// cases is the path of select cases like ["case=dative", "gender=female"]. It is empty if the value isn't select form.
+	cases  []string
+	plural bool
+	// bounds keeps min and max of pluralisations. nil means null in JSON.
+	bounds  [][2]interface{}
+	texts   []string
+	fuzzy   bool
+	html    bool
+	comment string
+	refs    []string
+}
+
+// contextSpecials are characters that have meanings in context strings. They are escaped with backslash.
+const contextSpecials = `\{}[]=,/`
+
+func escapeContext(text string) string {
+	var result strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(contextSpecials, r) {
+			result.WriteByte('\\')
+		}
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+func unescapeContext(text string) string {
+	var result strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			i++
+		}
+		result.WriteByte(text[i])
+	}
+	return result.String()
+}
+
+// unescapedIndexes returns indexes of c in text that are not escaped.
+func unescapedIndexes(text string, c byte) []int {
+	var result []int
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case c:
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// splitUnescaped splits text at sep that is not escaped. Parts are kept escaped.
+func splitUnescaped(text string, sep byte) []string {
+	var result []string
+	last := 0
+	for _, index := range unescapedIndexes(text, sep) {
+		result = append(result, text[last:index])
+		last = index + 1
+	}
+	return append(result, text[last:])
+}
+
+// escapePair escapes key and value of a pair like "gender=female".
+func escapePair(key, value string) string {
+	return escapeContext(key) + "=" + escapeContext(value)
+}
+
+// parsePair parses a pair that escapePair makes.
+func parsePair(text string) (string, string, bool) {
+	indexes := unescapedIndexes(text, '=')
+	if len(indexes) == 0 {
+		return "", "", false
+	}
+	return unescapeContext(text[:indexes[0]]), unescapeContext(text[indexes[0]+1:]), true
+}
+
+// contextString returns namespace and context like "billing{plan=free, region=eu}".
+// Special characters in namespace, keys and values are escaped with backslash.
+func contextString(namespace string, matches map[string]string) string {
+	if len(matches) == 0 {
+		return escapeContext(namespace)
+	}
+	var conditions []string
+	for key, value := range matches {
+		conditions = append(conditions, escapePair(key, value))
+	}
+	sort.Strings(conditions)
+	return escapeContext(namespace) + "{" + strings.Join(conditions, ", ") + "}"
+}
+
+func parseContextString(context string) (string, map[string]string) {
+	opens := unescapedIndexes(context, '{')
+	closes := unescapedIndexes(context, '}')
+	if len(opens) == 0 || len(closes) == 0 || closes[len(closes)-1] != len(context)-1 {
+		return unescapeContext(context), nil
+	}
+	index := opens[0]
+	matches := make(map[string]string)
+	for _, condition := range splitUnescaped(context[index+1:len(context)-1], ',') {
+		if key, value, ok := parsePair(strings.TrimPrefix(condition, " ")); ok {
+			matches[key] = value
+		}
+	}
+	return unescapeContext(context[:index]), matches
+}
+
+// catalogContext returns contextString of the entry and the path of select cases like "billing{plan=free}[gender=female]".
+func catalogContext(entry *catalogEntry) string {
+	result := contextString(entry.namespace, entry.matches)
+	if len(entry.cases) > 0 {
+		cases := make([]string, len(entry.cases))
+		for i, nameCase := range entry.cases {
+			parts := strings.SplitN(nameCase, "=", 2)
+			if len(parts) == 2 {
+				cases[i] = escapePair(parts[0], parts[1])
+			} else {
+				cases[i] = escapeContext(nameCase)
+			}
+		}
+		result += "[" + strings.Join(cases, "/") + "]"
+	}
+	return result
+}
+
+func parseCatalogContext(context string) (string, map[string]string, []string) {
+	var cases []string
+	opens := unescapedIndexes(context, '[')
+	closes := unescapedIndexes(context, ']')
+	if len(opens) > 0 && len(closes) > 0 && closes[len(closes)-1] == len(context)-1 {
+		index := opens[len(opens)-1]
+		for _, nameCase := range splitUnescaped(context[index+1:len(context)-1], '/') {
+			if name, caseName, ok := parsePair(nameCase); ok {
+				cases = append(cases, name+"="+caseName)
+			} else {
+				cases = append(cases, unescapeContext(nameCase))
+			}
+		}
+		context = context[:index]
+	}
+	namespace, matches := parseContextString(context)
+	return namespace, matches, cases
+}
+
+// updateCase updates the translation at the path of select cases with update function.
+// Select forms on the path are created if they don't exist.
+func updateCase(value interface{}, path []string, update func(value interface{}) interface{}) interface{} {
+	if len(path) == 0 {
+		return update(value)
+	}
+	nameCase := strings.SplitN(path[0], "=", 2)
+	if len(nameCase) != 2 {
+		return update(value)
+	}
+	name, cases, ok := selectForm(value)
+	if !ok || name != nameCase[0] {
+		cases = make(map[string]interface{})
+		value = map[string]interface{}{"select": nameCase[0], "cases": cases}
+	}
+	cases[nameCase[1]] = updateCase(cases[nameCase[1]], path[1:], update)
+	return value
+}
+
+// selectParent returns the select form that has the case at the end of path.
+func selectParent(value interface{}, path []string) map[string]interface{} {
+	for i, nameCase := range path {
+		parts := strings.SplitN(nameCase, "=", 2)
+		name, cases, ok := selectForm(value)
+		if !ok || len(parts) != 2 || name != parts[0] {
+			return nil
+		}
+		if i == len(path)-1 {
+			return value.(map[string]interface{})
+		}
+		value = cases[parts[1]]
+	}
+	return nil
+}
+
+// formatRange returns pluralisation range like "1..1", "2.." or "..0".
+func formatRange(bounds [2]interface{}) string {
+	format := func(bound interface{}) string {
+		if bound == nil {
+			return ""
+		}
+		return fmt.Sprint(bound)
+	}
+	return format(bounds[0]) + ".." + format(bounds[1])
+}
+
+func parseRange(text string) ([2]interface{}, error) {
+	var result [2]interface{}
+	parts := strings.SplitN(text, "..", 2)
+	if len(parts) != 2 {
+		return result, fmt.Errorf("invalid pluralisation range '%s'", text)
+	}
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return result, fmt.Errorf("invalid pluralisation range '%s'", text)
+		}
+		result[i] = value
+	}
+	return result, nil
+}
+
+// entries returns all translations in the dictionary. Common namespace comes first,
+// and keys are sorted in root values and each context.
+func (d *dictionary) entries() []*catalogEntry {
+	var result []*catalogEntry
+	names := append([]string{""}, d.namespaceNames()...)
+	for _, name := range names {
+		dict := d.namespace(name, false)
+		html := make(map[string]bool)
+		for _, key := range dict.HTML {
+			html[key] = true
+		}
+		add := func(values map[string]interface{}, matches map[string]string) {
+			keys := make([]string, 0, len(values))
+			for key := range values {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				var meta keyMeta
+				if found, ok := dict.Meta[key]; ok {
+					meta = *found
+				}
+				eachCase(values[key], nil, dict.isFuzzy(matches, key), func(cases []string, value interface{}, fuzzy bool) {
+					entry := &catalogEntry{
+						namespace: name,
+						matches:   matches,
+						key:       key,
+						cases:     cases,
+						fuzzy:     fuzzy,
+						html:      html[key],
+						comment:   meta.Comment,
+						refs:      meta.Refs,
+					}
+					if pluralisations, ok := value.([]interface{}); ok {
+						entry.plural = true
+						for _, pluralisation := range pluralisations {
+							spec, ok := pluralisation.([]interface{})
+							if !ok || len(spec) != 3 {
+								continue
+							}
+							text, _ := spec[2].(string)
+							entry.bounds = append(entry.bounds, [2]interface{}{spec[0], spec[1]})
+							entry.texts = append(entry.texts, text)
+						}
+					} else {
+						text, _ := value.(string)
+						entry.texts = []string{text}
+					}
+					result = append(result, entry)
+				})
+			}
+		}
+		add(dict.Values, nil)
+		for _, context := range dict.Contexts {
+			add(context.Values, context.Matches)
+		}
+	}
+	return result
+}
+
+// eachCase calls fn with each translation in select form and the path of its cases in the order of case names.
+// Value that isn't select form is passed as is. Cases in fuzzy lists of select forms (and all cases if fuzzy is true)
+// are passed as fuzzy.
+func eachCase(value interface{}, path []string, fuzzy bool, fn func(path []string, value interface{}, fuzzy bool)) {
+	name, cases, ok := selectForm(value)
+	if !ok {
+		fn(path, value, fuzzy)
+		return
+	}
+	fuzzyNames := fuzzyCases(value.(map[string]interface{}))
+	for _, caseName := range sortedKeys(cases) {
+		casePath := append(append([]string{}, path...), name+"="+caseName)
+		eachCase(cases[caseName], casePath, fuzzy || containsString(fuzzyNames, caseName), fn)
+	}
+}
+
+// newDictionaryFromEntries builds dictionary from entries that are read from PO or XLIFF files.
+func newDictionaryFromEntries(entries []*catalogEntry) *dictionary {
+	result := newDictionary()
+	for _, entry := range entries {
+		var value interface{}
+		if entry.plural {
+			pluralisations := make([]interface{}, len(entry.texts))
+			for i, text := range entry.texts {
+				var bounds [2]interface{}
+				if i < len(entry.bounds) {
+					bounds = entry.bounds[i]
+				}
+				pluralisations[i] = []interface{}{bounds[0], bounds[1], text}
+			}
+			value = pluralisations
+		} else if len(entry.texts) > 0 {
+			value = entry.texts[0]
+		} else {
+			value = ""
+		}
+		result.addCaseIfNotExists(entry.namespace, entry.key, entry.cases, value, entry.matches)
+		if entry.fuzzy {
+			result.markFuzzy(entry.namespace, entry.key, entry.matches, entry.cases)
+		}
+		dict := result.namespace(entry.namespace, true)
+		if entry.html && !containsString(dict.HTML, entry.key) {
+			dict.HTML = append(dict.HTML, entry.key)
+			sort.Strings(dict.HTML)
+		}
+		if entry.comment != "" || len(entry.refs) > 0 {
+			meta := result.meta(entry.namespace, entry.key)
+			if meta.Comment == "" {
+				meta.Comment = entry.comment
+			}
+			for _, ref := range entry.refs {
+				if !containsString(meta.Refs, ref) {
+					meta.Refs = append(meta.Refs, ref)
+				}
+			}
+		}
+	}
+	return result
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
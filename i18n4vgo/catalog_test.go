@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestContextString(t *testing.T) {
+	tests := []struct {
+		namespace string
+		matches   map[string]string
+		cases     []string
+	}{
+		{"", nil, nil},
+		{"billing", map[string]string{"plan": "free", "region": "eu"}, nil},
+		{"a{b}", map[string]string{"x=y": "1, 2", "z": "{c}"}, nil},
+		{"path/to\\ns", nil, []string{"gender=female", "case=dat[ive]/x"}},
+		{"", map[string]string{"gender": "female"}, []string{"mood=a=b"}},
+	}
+	for _, test := range tests {
+		entry := &catalogEntry{namespace: test.namespace, matches: test.matches, cases: test.cases}
+		context := catalogContext(entry)
+		namespace, matches, cases := parseCatalogContext(context)
+		if namespace != test.namespace || !reflect.DeepEqual(matches, test.matches) || !reflect.DeepEqual(cases, test.cases) {
+			t.Errorf("'%s' should be parsed as %q, %v, %q, but %q, %v, %q", context, test.namespace, test.matches, test.cases, namespace, matches, cases)
+		}
+	}
+}
+
+const roundTripSource = `{
+    "values": {
+        "Save": "保存",
+        "%n files": [[null, 0, "ファイルがありません"], [1, null, "%n 個のファイル"]],
+        "%{name} replied": {
+            "select": "gender",
+            "cases": {
+                "female": {
+                    "select": "case",
+                    "cases": {"dative": "%{name}さん(彼女)へ返信", "other": "%{name}さん(彼女)が返信"}
+                },
+                "other": "%{name}さんが返信"
+            },
+            "fuzzy": ["other"]
+        },
+        "<b>Bold</b>": "<b>太字</b>",
+        "Draft": "下書き"
+    },
+    "contexts": [
+        {
+            "matches": {"plan": "a=b, {c}", "region": "eu/west"},
+            "values": {"Save": "保存する"}
+        }
+    ],
+    "html": ["<b>Bold</b>"],
+    "fuzzy": ["Draft"],
+    "meta": {
+        "Save": {"refs": ["app/page.go:12", "app/form.go:3"], "comment": "button label\nkeep it short"}
+    },
+    "namespaces": {
+        "billing{v2}": {
+            "values": {"Pay": "支払う"},
+            "contexts": [
+                {"matches": {"gender": "female"}, "values": {"Pay": "支払う(彼女)"}}
+            ],
+            "meta": {"Pay": {"comment": "payment button"}}
+        }
+    }
+}`
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []string{"po", "xliff"} {
+		dir := writeFiles(t, map[string]string{"ja.json": roundTripSource})
+		input := filepath.Join(dir, "ja.json")
+		exported := filepath.Join(dir, "ja."+format)
+		imported := filepath.Join(dir, "imported.json")
+		if err := exportDictionary(input, exported, format, "", "en"); err != nil {
+			t.Fatal(err)
+		}
+		if err := importDictionary(exported, imported, format); err != nil {
+			t.Fatal(err)
+		}
+		original, err := loadDictionary(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := loadDictionary(imported)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := original.entries()
+		actual := result.entries()
+		if len(actual) != len(expected) {
+			t.Fatalf("%s: %d entries should be imported, but %d\n%s", format, len(expected), len(actual), readFile(t, exported))
+		}
+		for i := range expected {
+			if !reflect.DeepEqual(actual[i], expected[i]) {
+				t.Errorf("%s: entry should be kept\nexpected: %+v\nactual:   %+v", format, expected[i], actual[i])
+			}
+		}
+		os.RemoveAll(dir)
+	}
+}
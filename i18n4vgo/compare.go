@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"github.com/shibukawa/i18n4v"
+	"os"
+)
+
+// compare checks target dictionaries with reference dictionary and returns exit code.
+// It returns 1 if any differences are found.
+func compare(reference string, targets []string, format string) int {
+	problems := []*i18n4v.Problem{}
+	for _, target := range targets {
+		if target == reference {
+			continue
+		}
+		found, err := compareFile(reference, target)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("%{file}: %{error}", i18n4v.Replace{"file": target, "error": err}))
+			return 2
+		}
+		problems = append(problems, found...)
+	}
+	return report(problems, format)
+}
+
+func compareFile(reference, target string) ([]*i18n4v.Problem, error) {
+	referenceFile, err := os.Open(reference)
+	if err != nil {
+		return nil, err
+	}
+	defer referenceFile.Close()
+	targetFile, err := os.Open(target)
+	if err != nil {
+		return nil, err
+	}
+	defer targetFile.Close()
+	problems, err := i18n4v.Compare(referenceFile, targetFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, problem := range problems {
+		problem.File = target
+	}
+	return problems, nil
+}
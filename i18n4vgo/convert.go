@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// formatFromExtension returns "po" or "xliff" from file extension.
+func formatFromExtension(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".xlf", ".xliff":
+		return "xliff"
+	}
+	return "po"
+}
+
+// exportDictionary writes JSON dictionary in PO or XLIFF format.
+// If locale is empty, it is taken from the file name of input.
+func exportDictionary(input, output, format, locale, sourceLanguage string) error {
+	dict, err := loadDictionary(input)
+	if err != nil {
+		return fmt.Errorf("%s: %v", input, err)
+	}
+	if locale == "" {
+		locale = localeName(input)
+	}
+	if format == "" {
+		format = formatFromExtension(output)
+	}
+	w := os.Stdout
+	if output != "" {
+		w, err = os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+	}
+	if format == "xliff" {
+		return writeXLIFF(w, dict.entries(), filepath.Base(input), sourceLanguage, locale)
+	}
+	return writePO(w, dict.entries(), locale)
+}
+
+// importDictionary reads PO or XLIFF file and writes it as JSON dictionary.
+func importDictionary(input, output, format string) error {
+	r, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if format == "" {
+		format = formatFromExtension(input)
+	}
+	var entries []*catalogEntry
+	if format == "xliff" {
+		entries, err = readXLIFF(r)
+	} else {
+		entries, err = readPO(r)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", input, err)
+	}
+	return newDictionaryFromEntries(entries).save(output)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type packageCoverage struct {
+	Package    string   `json:"package"`
+	Total      int      `json:"total"`
+	Translated int      `json:"translated"`
+	Percent    float64  `json:"percent"`
+	Missing    []string `json:"missing,omitempty"`
+}
+
+type localeCoverage struct {
+	Locale     string             `json:"locale"`
+	File       string             `json:"file"`
+	Total      int                `json:"total"`
+	Translated int                `json:"translated"`
+	Percent    float64            `json:"percent"`
+	Packages   []*packageCoverage `json:"packages"`
+}
+
+func percent(translated, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(translated) * 100 / float64(total)
+}
+
+// localeName returns locale name from dictionary file name like "locales/ja.json".
+func localeName(file string) string {
+	base := filepath.Base(file)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// isTranslated checks whether the dictionary has real translation of the key.
+// Empty strings, copies of the key (or default text) made by --fill-copy, fuzzy drafts and
+// machine drafts that are not reviewed yet are not real translations.
+func isTranslated(dict *dictionary, found *usage) bool {
+	if found.namespace != "" {
+		if namespace := dict.namespace(found.namespace, false); namespace != nil && hasTranslation(namespace, found) {
+			return true
+		}
+	}
+	return hasTranslation(dict, found)
+}
+
+// hasTranslation checks real translation of the key in dict. Namespaces of dict are not searched.
+func hasTranslation(dict *dictionary, found *usage) bool {
+	if meta, ok := dict.Meta[found.key]; ok && meta.Machine != "" {
+		return false
+	}
+	for _, value := range dict.lookup("", found.key) {
+		for _, text := range translationTexts(value) {
+			if text != "" && text != found.key && text != found.defaultText {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// calcCoverage calculates ratio of used keys that have real translations in a locale.
+func calcCoverage(file string, dict *dictionary, usages []*usage) *localeCoverage {
+	result := &localeCoverage{
+		Locale: localeName(file),
+		File:   file,
+	}
+	packages := make(map[string]*packageCoverage)
+	checked := make(map[string]bool)
+	checkedInPackage := make(map[string]bool)
+	for _, found := range usages {
+		pkg, ok := packages[found.pkg]
+		if !ok {
+			pkg = &packageCoverage{Package: found.pkg}
+			packages[found.pkg] = pkg
+			result.Packages = append(result.Packages, pkg)
+		}
+		translated := isTranslated(dict, found)
+		id := found.namespace + "\x00" + found.key
+		if !checkedInPackage[found.pkg+"\x00"+id] {
+			checkedInPackage[found.pkg+"\x00"+id] = true
+			pkg.Total++
+			if translated {
+				pkg.Translated++
+			} else {
+				pkg.Missing = append(pkg.Missing, found.name())
+			}
+		}
+		if !checked[id] {
+			checked[id] = true
+			result.Total++
+			if translated {
+				result.Translated++
+			}
+		}
+	}
+	result.Percent = percent(result.Translated, result.Total)
+	sort.Slice(result.Packages, func(i, j int) bool {
+		return result.Packages[i].Package < result.Packages[j].Package
+	})
+	for _, pkg := range result.Packages {
+		pkg.Percent = percent(pkg.Translated, pkg.Total)
+		sort.Strings(pkg.Missing)
+	}
+	return result
+}
+
+func coverage(usages []*usage, localeFiles []string) ([]*localeCoverage, error) {
+	var result []*localeCoverage
+	for _, file := range localeFiles {
+		dict, err := loadDictionary(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		result = append(result, calcCoverage(file, dict, usages))
+	}
+	return result, nil
+}
+
+func writeCoverage(w io.Writer, coverages []*localeCoverage, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		return enc.Encode(coverages)
+	case "html":
+		return coverageTemplate.Execute(w, coverages)
+	}
+	for _, locale := range coverages {
+		fmt.Fprintf(w, "%s: %.1f%% (%d/%d)\n", locale.Locale, locale.Percent, locale.Translated, locale.Total)
+		for _, pkg := range locale.Packages {
+			fmt.Fprintf(w, "    %s: %.1f%% (%d/%d)\n", pkg.Package, pkg.Percent, pkg.Translated, pkg.Total)
+		}
+	}
+	return nil
+}
+
+var coverageTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Translation Coverage</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.bar { background: #eee; width: 200px; height: 1em; }
+.bar div { background: #4caf50; height: 100%; }
+details { font-size: small; }
+</style>
+</head>
+<body>
+<h1>Translation Coverage</h1>
+{{range .}}
+<h2>{{.Locale}}: {{printf "%.1f" .Percent}}% ({{.Translated}}/{{.Total}})</h2>
+<table>
+<tr><th>Package</th><th>Coverage</th><th></th><th>Untranslated keys</th></tr>
+{{range .Packages}}
+<tr>
+<td>{{.Package}}</td>
+<td>{{printf "%.1f" .Percent}}% ({{.Translated}}/{{.Total}})</td>
+<td><div class="bar"><div style="width: {{printf "%.0f" .Percent}}%"></div></div></td>
+<td>{{if .Missing}}<details><summary>{{len .Missing}} keys</summary><ul>{{range .Missing}}<li>{{.}}</li>{{end}}</ul></details>{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
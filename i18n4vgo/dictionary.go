@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// dictionary is an editable form of translation JSON.
+type dictionary struct {
+	Values   map[string]interface{} `json:"values,omitempty"`
+	Contexts []*dictionaryContext   `json:"contexts,omitempty"`
+	HTML     []string               `json:"html,omitempty"`
+	// Fuzzy keeps keys in root values that have draft translations (suggested from similar keys or filled by providers).
+	// Contexts and select forms have their own fuzzy lists.
+	Fuzzy []string `json:"fuzzy,omitempty"`
+	// Meta keeps source references and comments for translators. The runtime ignores it.
+	Meta map[string]*keyMeta `json:"meta,omitempty"`
+	// Namespaces keeps dictionaries of namespaces. Keys in them are looked up before common (root) dictionary.
+	Namespaces map[string]*dictionary `json:"namespaces,omitempty"`
+	// Obsolete keeps entries that are not used in sources anymore. The runtime ignores it.
+	Obsolete *dictionary `json:"obsolete,omitempty"`
+}
+
+// keyMeta is metadata of a key for translators.
+type keyMeta struct {
+	// Refs are source references like "app/page.go:12".
+	Refs []string `json:"refs,omitempty"`
+	// Comment is a note from developers.
+	Comment string `json:"comment,omitempty"`
+	// Machine is the name of the provider that drafted the translation. Translators should review it and remove this field.
+	// Coverage doesn't count machine drafts as translated.
+	Machine string `json:"machine,omitempty"`
+	// Source is the hash of source texts in reference dictionary when the key is translated (see i18n4v.SourceHash).
+	Source string `json:"source,omitempty"`
+}
+
+type dictionaryContext struct {
+	Matches map[string]string      `json:"matches"`
+	Values  map[string]interface{} `json:"values"`
+	// Fuzzy keeps keys in this context that have draft translations.
+	Fuzzy []string `json:"fuzzy,omitempty"`
+}
+
+func newDictionary() *dictionary {
+	return &dictionary{
+		Values: make(map[string]interface{}),
+	}
+}
+
+func loadDictionary(path string) (*dictionary, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	result := newDictionary()
+	err = json.Unmarshal(data, result)
+	if err != nil {
+		return nil, err
+	}
+	result.normalize()
+	for _, namespace := range result.Namespaces {
+		namespace.normalize()
+	}
+	return result, nil
+}
+
+// normalize fills nil maps that are omitted in JSON.
+func (d *dictionary) normalize() {
+	if d.Values == nil {
+		d.Values = make(map[string]interface{})
+	}
+	for _, context := range d.Contexts {
+		if context.Values == nil {
+			context.Values = make(map[string]interface{})
+		}
+	}
+	if d.Obsolete != nil {
+		d.Obsolete.normalize()
+	}
+}
+
+// loadOrCreateDictionary loads dictionary. If the file doesn't exist, it returns empty dictionary.
+func loadOrCreateDictionary(path string) (*dictionary, error) {
+	result, err := loadDictionary(path)
+	if os.IsNotExist(err) {
+		return newDictionary(), nil
+	}
+	return result, err
+}
+
+func (d *dictionary) save(path string) error {
+	data, err := d.marshal()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// marshal returns JSON in the same format as save writes.
+func (d *dictionary) marshal() ([]byte, error) {
+	var buffer bytes.Buffer
+	enc := json.NewEncoder(&buffer)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	err := enc.Encode(d)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// context returns context entry that has same matches. If matches is nil, it returns nil.
+func (d *dictionary) context(matches map[string]string, create bool) *dictionaryContext {
+	if matches == nil {
+		return nil
+	}
+	for _, context := range d.Contexts {
+		if reflect.DeepEqual(context.Matches, matches) {
+			return context
+		}
+	}
+	if !create {
+		return nil
+	}
+	context := &dictionaryContext{
+		Matches: matches,
+		Values:  make(map[string]interface{}),
+	}
+	d.Contexts = append(d.Contexts, context)
+	return context
+}
+
+// namespace returns dictionary of the namespace. If name is empty, it returns d itself.
+func (d *dictionary) namespace(name string, create bool) *dictionary {
+	if name == "" {
+		return d
+	}
+	result, ok := d.Namespaces[name]
+	if !ok && create {
+		result = newDictionary()
+		if d.Namespaces == nil {
+			d.Namespaces = make(map[string]*dictionary)
+		}
+		d.Namespaces[name] = result
+	}
+	return result
+}
+
+// namespaceNames returns names of namespaces in sorted order.
+func (d *dictionary) namespaceNames() []string {
+	var result []string
+	for name := range d.Namespaces {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (d *dictionary) addIfNotExists(namespace, key string, value interface{}, matches map[string]string) {
+	d = d.namespace(namespace, true)
+	values := d.Values
+	if context := d.context(matches, true); context != nil {
+		values = context.Values
+	}
+	if _, ok := values[key]; !ok {
+		values[key] = value
+	}
+}
+
+// addCaseIfNotExists adds value at the path of select cases of the key.
+// Existing translations are kept like addIfNotExists.
+func (d *dictionary) addCaseIfNotExists(namespace, key string, cases []string, value interface{}, matches map[string]string) {
+	if len(cases) == 0 {
+		d.addIfNotExists(namespace, key, value, matches)
+		return
+	}
+	d = d.namespace(namespace, true)
+	values := d.Values
+	if context := d.context(matches, true); context != nil {
+		values = context.Values
+	}
+	values[key] = updateCase(values[key], cases, func(current interface{}) interface{} {
+		if current != nil {
+			return current
+		}
+		return value
+	})
+}
+
+// has returns true if the namespace has the key in the context (or root values if matches is nil).
+func (d *dictionary) has(namespace, key string, matches map[string]string) bool {
+	d = d.namespace(namespace, false)
+	if d == nil {
+		return false
+	}
+	values := d.Values
+	if matches != nil {
+		context := d.context(matches, false)
+		if context == nil {
+			return false
+		}
+		values = context.Values
+	}
+	_, ok := values[key]
+	return ok
+}
+
+// isFuzzy returns true if the key in the context (or root values if matches is nil) is draft translation.
+func (d *dictionary) isFuzzy(matches map[string]string, key string) bool {
+	if list := d.fuzzyList(matches, false); list != nil {
+		return containsString(*list, key)
+	}
+	return false
+}
+
+// fuzzyList returns fuzzy list of the context that has matches, or root values if matches is nil.
+func (d *dictionary) fuzzyList(matches map[string]string, create bool) *[]string {
+	if matches == nil {
+		return &d.Fuzzy
+	}
+	if context := d.context(matches, create); context != nil {
+		return &context.Fuzzy
+	}
+	return nil
+}
+
+// meta returns metadata of the key in the namespace. If it doesn't exist, it is created.
+func (d *dictionary) meta(namespace, key string) *keyMeta {
+	d = d.namespace(namespace, true)
+	if d.Meta == nil {
+		d.Meta = make(map[string]*keyMeta)
+	}
+	result, ok := d.Meta[key]
+	if !ok {
+		result = &keyMeta{}
+		d.Meta[key] = result
+	}
+	return result
+}
+
+// markFuzzy marks the entry of the key in the namespace as draft translation.
+// The entry is in the context that has matches (or root values if matches is nil), and
+// it is the case at the end of cases if the value is select form.
+func (d *dictionary) markFuzzy(namespace, key string, matches map[string]string, cases []string) {
+	d = d.namespace(namespace, true)
+	if len(cases) > 0 {
+		values := d.Values
+		if context := d.context(matches, true); context != nil {
+			values = context.Values
+		}
+		if form := selectParent(values[key], cases); form != nil {
+			// the last element of path is like "gender=female"
+			last := cases[len(cases)-1]
+			markFuzzyCase(form, last[strings.Index(last, "=")+1:])
+		}
+		return
+	}
+	list := d.fuzzyList(matches, true)
+	if !containsString(*list, key) {
+		*list = append(*list, key)
+		sort.Strings(*list)
+	}
+}
+
+// lookup returns all reviewed translations of key in root values and contexts.
+// If namespace is not empty, translations in the namespace come first.
+// Fuzzy entries are skipped, and fuzzy cases are removed from select forms.
+func (d *dictionary) lookup(namespace, key string) []interface{} {
+	var result []interface{}
+	if namespace != "" {
+		if dict := d.namespace(namespace, false); dict != nil {
+			result = dict.lookup("", key)
+		}
+	}
+	if value, ok := d.Values[key]; ok && !d.isFuzzy(nil, key) {
+		result = append(result, reviewedValue(value))
+	}
+	for _, context := range d.Contexts {
+		if value, ok := context.Values[key]; ok && !containsString(context.Fuzzy, key) {
+			result = append(result, reviewedValue(value))
+		}
+	}
+	return result
+}
+
+// sourceTexts returns texts of the key in the namespace to calculate i18n4v.SourceHash.
+// They are texts in root values and then contexts. Empty text is replaced with key.
+func (d *dictionary) sourceTexts(namespace, key string) []string {
+	dict := d.namespace(namespace, false)
+	if dict == nil {
+		return nil
+	}
+	var result []string
+	if value, ok := dict.Values[key]; ok {
+		result = append(result, translationTexts(value)...)
+	}
+	for _, context := range dict.Contexts {
+		if value, ok := context.Values[key]; ok {
+			result = append(result, translationTexts(value)...)
+		}
+	}
+	for i, text := range result {
+		if text == "" {
+			result[i] = key
+		}
+	}
+	return result
+}
+
+// translationTexts returns texts in translation value (string, pluralisation array or select form).
+// Texts of select cases are returned in the order of case names.
+func translationTexts(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case map[string]interface{}:
+		_, cases, ok := selectForm(v)
+		if !ok {
+			return nil
+		}
+		var result []string
+		for _, name := range sortedKeys(cases) {
+			result = append(result, translationTexts(cases[name])...)
+		}
+		return result
+	case []interface{}:
+		var result []string
+		for _, pluralisation := range v {
+			spec, ok := pluralisation.([]interface{})
+			if !ok || len(spec) != 3 {
+				continue
+			}
+			if text, ok := spec[2].(string); ok {
+				result = append(result, text)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// reviewedValue returns the translation value without fuzzy cases of select forms.
+func reviewedValue(value interface{}) interface{} {
+	name, cases, ok := selectForm(value)
+	if !ok {
+		return value
+	}
+	fuzzy := fuzzyCases(value.(map[string]interface{}))
+	reviewed := make(map[string]interface{}, len(cases))
+	for caseName, caseValue := range cases {
+		if !containsString(fuzzy, caseName) {
+			reviewed[caseName] = reviewedValue(caseValue)
+		}
+	}
+	return map[string]interface{}{"select": name, "cases": reviewed}
+}
+
+// fuzzyCases returns case names that are draft translations in the select form.
+func fuzzyCases(form map[string]interface{}) []string {
+	var result []string
+	switch v := form["fuzzy"].(type) {
+	case []string:
+		result = v
+	case []interface{}:
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				result = append(result, name)
+			}
+		}
+	}
+	return result
+}
+
+func markFuzzyCase(form map[string]interface{}, caseName string) {
+	fuzzy := fuzzyCases(form)
+	if !containsString(fuzzy, caseName) {
+		fuzzy = append(append([]string{}, fuzzy...), caseName)
+		sort.Strings(fuzzy)
+		form["fuzzy"] = fuzzy
+	}
+}
+
+// selectForm returns selector name and cases of select form like {"select": "gender", "cases": {...}}.
+func selectForm(value interface{}) (string, map[string]interface{}, bool) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return "", nil, false
+	}
+	name, _ := object["select"].(string)
+	cases, ok := object["cases"].(map[string]interface{})
+	if name == "" || !ok {
+		return "", nil, false
+	}
+	return name, cases, true
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	result := make([]string, 0, len(values))
+	for key := range values {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// initialValue returns value for new key. If fillCopy is true, it uses text as translation.
+func initialValue(found *usage, fillCopy bool) interface{} {
+	text := ""
+	if fillCopy {
+		text = found.key
+		if found.defaultText != "" {
+			text = found.defaultText
+		}
+	}
+	if found.plural {
+		// ranges cover all counts so that lint doesn't report gaps
+		return []interface{}{
+			[]interface{}{nil, 0, text},
+			[]interface{}{1, 1, text},
+			[]interface{}{2, nil, text},
+		}
+	}
+	return text
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// diffOp is an operation of line diff. kind is ' ' (same), '-' (removed) or '+' (added).
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// diffLines returns the shortest edit script from a to b (Myers' algorithm).
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	// trace keeps v at the start of each step for backtracking
+	var trace [][]int
+	found := false
+	for d := 0; d <= n+m && !found; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	var reversed []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, diffOp{'+', b[y-1]})
+			y--
+		} else {
+			reversed = append(reversed, diffOp{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, diffOp{' ', a[x-1]})
+		x--
+		y--
+	}
+	result := make([]diffOp, len(reversed))
+	for i, op := range reversed {
+		result[len(reversed)-1-i] = op
+	}
+	return result
+}
+
+// diffContext is number of unchanged lines around changes in unified diff.
+const diffContext = 3
+
+// writeUnifiedDiff writes difference between a and b in unified diff format.
+// It writes nothing if they are the same.
+func writeUnifiedDiff(w io.Writer, name string, a, b []string) error {
+	ops := diffLines(a, b)
+	// lines of a and b that each operation starts at (0 origin)
+	aLines := make([]int, len(ops)+1)
+	bLines := make([]int, len(ops)+1)
+	var changes []int
+	for i, op := range ops {
+		aLines[i+1], bLines[i+1] = aLines[i], bLines[i]
+		if op.kind != '+' {
+			aLines[i+1]++
+		}
+		if op.kind != '-' {
+			bLines[i+1]++
+		}
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", name, name); err != nil {
+		return err
+	}
+	for i := 0; i < len(changes); {
+		start := changes[i] - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := changes[i] + diffContext + 1
+		// merge changes whose contexts overlap
+		for i++; i < len(changes) && changes[i]-diffContext <= end; i++ {
+			end = changes[i] + diffContext + 1
+		}
+		if end > len(ops) {
+			end = len(ops)
+		}
+		aStart, aCount := aLines[start], aLines[end]-aLines[start]
+		bStart, bCount := bLines[start], bLines[end]-bLines[start]
+		if aCount > 0 {
+			aStart++
+		}
+		if bCount > 0 {
+			bStart++
+		}
+		if _, err := fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount); err != nil {
+			return err
+		}
+		for _, op := range ops[start:end] {
+			if _, err := fmt.Fprintf(w, "%c%s\n", op.kind, op.text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
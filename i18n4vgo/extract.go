@@ -0,0 +1,607 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const i18n4vPackage = "github.com/shibukawa/i18n4v"
+
+// usage is a translation key that is found in source code.
+type usage struct {
+	key         string
+	defaultText string
+	plural      bool
+	context     map[string]string
+	namespace   string
+	comment     string
+	file        string
+	line        int
+	pkg         string
+}
+
+// ref returns source reference like "app/page.go:12".
+func (u *usage) ref() string {
+	return filepath.ToSlash(u.file) + ":" + strconv.Itoa(u.line)
+}
+
+// name returns key with namespace like "[billing] Save" for reports.
+func (u *usage) name() string {
+	if u.namespace == "" {
+		return u.key
+	}
+	return "[" + u.namespace + "] " + u.key
+}
+
+// findSourceFiles returns Go files and template files (that have one of templateExts) in paths.
+// Test files, vendor and hidden directories are skipped.
+func findSourceFiles(paths []string, exclude *regexp.Regexp, templateExts []string) ([]string, []string, error) {
+	var goFiles, templateFiles []string
+	found := make(map[string]bool)
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				name := info.Name()
+				if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if exclude != nil && exclude.MatchString(path) || found[path] {
+				return nil
+			}
+			if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+				found[path] = true
+				goFiles = append(goFiles, path)
+				return nil
+			}
+			for _, ext := range templateExts {
+				if strings.HasSuffix(path, ext) {
+					found[path] = true
+					templateFiles = append(templateFiles, path)
+					return nil
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	sort.Strings(goFiles)
+	sort.Strings(templateFiles)
+	return goFiles, templateFiles, nil
+}
+
+// extractGo parses Go files and returns translation keys in them.
+//
+// It finds calls of i18n4v.Translate, Translate method of i18n4v.Translator and calls of functions that
+// are initialized by i18n4v.Translate, i18n4v.Select or i18n4v.SelectWithRequest
+// in the same package. Function names in funcs are treated as translation functions too.
+//
+// Calls via translators that are scoped by Namespace("name") method keep the namespace.
+// Values of struct tags in tags (like `msg:"Name is required"`) are treated as keys too.
+func extractGo(files []string, funcs []string, tags []string) ([]*usage, error) {
+	fset := token.NewFileSet()
+	parsed := make([]*ast.File, len(files))
+	// translators keeps names of translation functions and their namespaces for each package.
+	translators := make(map[string]map[string]string)
+	// scoped keeps names of variables that keep scoped translators for each package.
+	scoped := make(map[string]map[string]string)
+	for i, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = f
+		pkg := filepath.Dir(file)
+		if translators[pkg] == nil {
+			translators[pkg] = make(map[string]string)
+			scoped[pkg] = make(map[string]string)
+			for _, name := range funcs {
+				translators[pkg][name] = ""
+			}
+		}
+		if importAlias(f) != "" {
+			findScopedTranslators(f, scoped[pkg])
+		}
+	}
+	for i, file := range files {
+		pkg := filepath.Dir(file)
+		if alias := importAlias(parsed[i]); alias != "" {
+			findTranslatorFunctions(parsed[i], alias, translators[pkg], scoped[pkg])
+		}
+	}
+	// argument types are resolved per package to tell count from default text and Replace variables
+	packageFiles := make(map[string][]*ast.File)
+	for i, file := range files {
+		pkg := filepath.Dir(file)
+		packageFiles[pkg] = append(packageFiles[pkg], parsed[i])
+	}
+	imports := newFallbackImporter()
+	infos := make(map[string]*types.Info)
+	for pkg, pkgFiles := range packageFiles {
+		infos[pkg] = typeCheck(fset, pkgFiles, imports)
+	}
+	var result []*usage
+	for i, file := range files {
+		pkg := filepath.Dir(file)
+		result = append(result, extractGoFile(fset, parsed[i], file, pkg, translators[pkg], scoped[pkg], infos[pkg])...)
+		result = append(result, extractStructTags(fset, parsed[i], file, pkg, tags)...)
+	}
+	return result, nil
+}
+
+// importAlias returns the name of i18n4v package in the file.
+// If the file doesn't import i18n4v, it returns empty string.
+func importAlias(f *ast.File) string {
+	for _, spec := range f.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if path != i18n4vPackage {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+		return "i18n4v"
+	}
+	return ""
+}
+
+// i18n4vStub declares API of i18n4v package that is used to find translators when its export data is not available.
+const i18n4vStub = `package i18n4v
+
+type Replace map[string]interface{}
+
+type Context map[string]string
+
+type TranslatorFunction func(text string, args ...interface{}) string
+
+type Translator struct{}
+
+func (t *Translator) Translate(text string, args ...interface{}) string { return text }
+func (t *Translator) TranslateHTML(text string, args ...interface{}) string { return text }
+func (t *Translator) Namespace(name string) *Translator { return t }
+func (t *Translator) LookupNamespace(name string) (*Translator, bool) { return t, true }
+
+func Translate(key string, args ...interface{}) string { return key }
+func Select(lang string) TranslatorFunction { return nil }
+func SelectWithRequest(r interface{}) TranslatorFunction { return nil }
+func SelectTranslator(lang string) *Translator { return nil }
+func SelectTranslatorWithRequest(r interface{}) *Translator { return nil }
+func Create(reader interface{}, tag ...interface{}) (*Translator, error) { return nil, nil }
+func CreateFromString(json string, tag ...interface{}) (*Translator, error) { return nil, nil }
+func CreateInNamespace(namespace string, reader interface{}, tag ...interface{}) (*Translator, error) { return nil, nil }
+func MustCreate(reader interface{}, tag ...interface{}) *Translator { return nil }
+func MustCreateFromString(json string, tag ...interface{}) *Translator { return nil }
+func CreatePseudo(t *Translator, options interface{}) *Translator { return nil }
+`
+
+// fallbackImporter imports packages from compiled export data. Packages that can't be imported
+// (like dependencies that are not downloaded) become empty packages, and their types are unknown.
+// i18n4v package is imported from i18n4vStub instead.
+type fallbackImporter struct {
+	base     types.Importer
+	packages map[string]*types.Package
+}
+
+func newFallbackImporter() *fallbackImporter {
+	return &fallbackImporter{
+		base:     importer.Default(),
+		packages: make(map[string]*types.Package),
+	}
+}
+
+func (i *fallbackImporter) Import(path string) (*types.Package, error) {
+	if pkg, ok := i.packages[path]; ok {
+		return pkg, nil
+	}
+	pkg, err := i.base.Import(path)
+	if err != nil && path == i18n4vPackage {
+		pkg, err = importStub(path, i18n4vStub)
+	}
+	if err != nil {
+		pkg = types.NewPackage(path, filepath.Base(path))
+		pkg.MarkComplete()
+	}
+	i.packages[path] = pkg
+	return pkg, nil
+}
+
+// importStub type-checks the source of the package.
+func importStub(path, src string) (*types.Package, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	return (&types.Config{}).Check(path, fset, []*ast.File{f}, nil)
+}
+
+// isTranslator returns true if expr is Translator of i18n4v package (or pointer to it).
+func isTranslator(info *types.Info, expr ast.Expr) bool {
+	if info == nil {
+		return false
+	}
+	t := info.TypeOf(expr)
+	if pointer, ok := t.(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == i18n4vPackage && obj.Name() == "Translator"
+}
+
+// typeCheck resolves types of expressions in files of a package. Type errors are ignored.
+func typeCheck(fset *token.FileSet, files []*ast.File, imports types.Importer) *types.Info {
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	config := &types.Config{
+		Importer: imports,
+		Error:    func(error) {},
+	}
+	config.Check(files[0].Name.Name, fset, files, info)
+	return info
+}
+
+func extractGoFile(fset *token.FileSet, f *ast.File, file, pkg string, translators, scoped map[string]string, info *types.Info) []*usage {
+	alias := importAlias(f)
+	notes := translatorComments(fset, f)
+	var result []*usage
+	ast.Inspect(f, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		var namespace string
+		switch fun := call.Fun.(type) {
+		case *ast.SelectorExpr:
+			if fun.Sel.Name != "Translate" || alias == "" {
+				return true
+			}
+			// other APIs that have Translate method (like translation service clients) are not translators
+			if !isPackageSelector(fun, alias, "Translate") {
+				if namespace, ok = namespaceOf(fun.X, scoped); !ok && !isTranslator(info, fun.X) {
+					return true
+				}
+			}
+		case *ast.Ident:
+			if namespace, ok = translators[fun.Name]; !ok {
+				return true
+			}
+		default:
+			return true
+		}
+		key, ok := stringLiteral(call.Args[0])
+		if !ok {
+			return true
+		}
+		line := fset.Position(call.Pos()).Line
+		comment := notes.trailing[line]
+		if comment == "" {
+			comment = notes.leading[line-1]
+		}
+		found := &usage{
+			key:       key,
+			namespace: namespace,
+			comment:   comment,
+			file:      file,
+			line:      line,
+			pkg:       pkg,
+		}
+		parseTranslateArgs(found, call.Args[1:], alias, info)
+		result = append(result, found)
+		return true
+	})
+	return result
+}
+
+// extractStructTags returns values of struct tags in tags as keys.
+func extractStructTags(fset *token.FileSet, f *ast.File, file, pkg string, tags []string) []*usage {
+	if len(tags) == 0 {
+		return nil
+	}
+	var result []*usage
+	ast.Inspect(f, func(node ast.Node) bool {
+		field, ok := node.(*ast.Field)
+		if !ok || field.Tag == nil {
+			return true
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return true
+		}
+		for _, name := range tags {
+			if key, ok := reflect.StructTag(tag).Lookup(name); ok && key != "" {
+				result = append(result, &usage{
+					key:  key,
+					file: file,
+					line: fset.Position(field.Tag.Pos()).Line,
+					pkg:  pkg,
+				})
+			}
+		}
+		return true
+	})
+	return result
+}
+
+// comments keeps comments for translators by line.
+type comments struct {
+	// leading keeps comments in own lines by their last lines. They are attached to calls in the next line.
+	leading map[int]string
+	// trailing keeps comments after code by their lines. They are attached to calls in the same line.
+	trailing map[int]string
+}
+
+// translatorComments returns comments for translators like "// i18n: shown on the checkout button".
+func translatorComments(fset *token.FileSet, f *ast.File) *comments {
+	result := &comments{
+		leading:  make(map[int]string),
+		trailing: make(map[int]string),
+	}
+	// codeEnds keeps the first end position of code in each line to find trailing comments.
+	codeEnds := make(map[int]token.Pos)
+	ast.Inspect(f, func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		if _, ok := node.(*ast.CommentGroup); ok {
+			return false
+		}
+		line := fset.Position(node.End()).Line
+		if end, ok := codeEnds[line]; !ok || node.End() < end {
+			codeEnds[line] = node.End()
+		}
+		return true
+	})
+	for _, group := range f.Comments {
+		var notes []string
+		for _, line := range strings.Split(group.Text(), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "i18n:") {
+				notes = append(notes, strings.TrimSpace(strings.TrimPrefix(line, "i18n:")))
+			}
+		}
+		if len(notes) == 0 {
+			continue
+		}
+		line := fset.Position(group.Pos()).Line
+		if end, ok := codeEnds[line]; ok && end <= group.Pos() {
+			result.trailing[line] = strings.Join(notes, "\n")
+		} else {
+			result.leading[fset.Position(group.End()).Line] = strings.Join(notes, "\n")
+		}
+	}
+	return result
+}
+
+// namespaceOf returns namespace of the translator expression like t.Namespace("billing")
+// or a variable that keeps scoped translator.
+func namespaceOf(expr ast.Expr, scoped map[string]string) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		selector, ok := e.Fun.(*ast.SelectorExpr)
+		if !ok || selector.Sel.Name != "Namespace" || len(e.Args) != 1 {
+			return "", false
+		}
+		return stringLiteral(e.Args[0])
+	case *ast.Ident:
+		namespace, ok := scoped[e.Name]
+		return namespace, ok
+	}
+	return "", false
+}
+
+// findScopedTranslators collects variable names that keep translators scoped by Namespace method.
+func findScopedTranslators(f *ast.File, scoped map[string]string) {
+	ast.Inspect(f, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.AssignStmt:
+			for i, rhs := range n.Rhs {
+				if i >= len(n.Lhs) {
+					break
+				}
+				if ident, ok := n.Lhs[i].(*ast.Ident); ok {
+					if namespace, ok := namespaceOf(rhs, nil); ok {
+						scoped[ident.Name] = namespace
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for i, value := range n.Values {
+				if i >= len(n.Names) {
+					break
+				}
+				if namespace, ok := namespaceOf(value, nil); ok {
+					scoped[n.Names[i].Name] = namespace
+				}
+			}
+		}
+		return true
+	})
+}
+
+// findTranslatorFunctions collects variable names that keep translation functions.
+// Functions that are method values of scoped translators (like t.Namespace("billing").Translate) keep the namespace.
+func findTranslatorFunctions(f *ast.File, alias string, translators, scoped map[string]string) {
+	translatorExpr := func(expr ast.Expr) (string, bool) {
+		if call, ok := expr.(*ast.CallExpr); ok {
+			expr = call.Fun
+			if isPackageSelector(expr, alias, "Select") || isPackageSelector(expr, alias, "SelectWithRequest") {
+				return "", true
+			}
+			return "", false
+		}
+		if isPackageSelector(expr, alias, "Translate") {
+			return "", true
+		}
+		if selector, ok := expr.(*ast.SelectorExpr); ok && selector.Sel.Name == "Translate" {
+			return namespaceOf(selector.X, scoped)
+		}
+		return "", false
+	}
+	ast.Inspect(f, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.AssignStmt:
+			for i, rhs := range n.Rhs {
+				if i >= len(n.Lhs) {
+					break
+				}
+				if namespace, ok := translatorExpr(rhs); ok {
+					if ident, ok := n.Lhs[i].(*ast.Ident); ok {
+						translators[ident.Name] = namespace
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			if n.Type != nil && isPackageSelector(n.Type, alias, "TranslatorFunction") {
+				for _, name := range n.Names {
+					if _, ok := translators[name.Name]; !ok {
+						translators[name.Name] = ""
+					}
+				}
+			}
+			for i, value := range n.Values {
+				if i >= len(n.Names) {
+					break
+				}
+				if namespace, ok := translatorExpr(value); ok {
+					translators[n.Names[i].Name] = namespace
+				}
+			}
+		}
+		return true
+	})
+}
+
+func isPackageSelector(expr ast.Expr, alias, name string) bool {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != name {
+		return false
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	return ok && ident.Name == alias
+}
+
+func isPackageType(expr ast.Expr, alias, name string) bool {
+	if alias == "." {
+		ident, ok := expr.(*ast.Ident)
+		return ok && ident.Name == name
+	}
+	return isPackageSelector(expr, alias, name)
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		value, err := strconv.Unquote(e.Value)
+		return value, err == nil
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		left, ok := stringLiteral(e.X)
+		if !ok {
+			return "", false
+		}
+		right, ok := stringLiteral(e.Y)
+		return left + right, ok
+	case *ast.ParenExpr:
+		return stringLiteral(e.X)
+	}
+	return "", false
+}
+
+// parseTranslateArgs reads the rest of arguments of Translate.
+// It follows the argument order: default text, count, Replace, Context.
+// Arguments are distinguished by their types. If the type of count candidate is not known,
+// the key (or default text) that has %n is treated as plural.
+func parseTranslateArgs(found *usage, args []ast.Expr, alias string, info *types.Info) {
+	if len(args) == 0 {
+		return
+	}
+	if text, ok := stringLiteral(args[0]); ok {
+		found.defaultText = text
+		args = args[1:]
+	} else if basicInfo(info, args[0])&types.IsString != 0 {
+		// default text that is not constant
+		args = args[1:]
+	}
+	for i, arg := range args {
+		lit, ok := arg.(*ast.CompositeLit)
+		switch {
+		case ok && isPackageType(lit.Type, alias, "Context"):
+			found.context = make(map[string]string)
+			for _, elt := range lit.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				key, ok := stringLiteral(kv.Key)
+				if !ok {
+					continue
+				}
+				value, ok := stringLiteral(kv.Value)
+				if !ok {
+					continue
+				}
+				found.context[key] = value
+			}
+			if len(found.context) == 0 {
+				found.context = nil
+			}
+		case ok:
+			// Replace
+		case i == 0:
+			kind := basicInfo(info, arg)
+			if kind&types.IsInteger != 0 {
+				found.plural = true
+			} else if kind == 0 && !isTyped(info, arg) {
+				found.plural = strings.Contains(found.key, "%n") || strings.Contains(found.defaultText, "%n")
+			}
+		}
+	}
+}
+
+// basicInfo returns information of the basic type of expr. It returns 0 for other types and unknown types.
+func basicInfo(info *types.Info, expr ast.Expr) types.BasicInfo {
+	if info == nil {
+		return 0
+	}
+	tv, ok := info.Types[expr]
+	if !ok || tv.Type == nil {
+		return 0
+	}
+	if basic, ok := tv.Type.Underlying().(*types.Basic); ok {
+		return basic.Info()
+	}
+	return 0
+}
+
+// isTyped returns true if the type of expr is resolved.
+func isTyped(info *types.Info, expr ast.Expr) bool {
+	if info == nil {
+		return false
+	}
+	tv, ok := info.Types[expr]
+	return ok && tv.Type != nil && tv.Type != types.Typ[types.Invalid]
+}
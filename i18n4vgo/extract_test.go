@@ -0,0 +1,292 @@
+package main
+
+import (
+	"github.com/shibukawa/i18n4v"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const goSource = `package app
+
+import (
+	"github.com/shibukawa/i18n4v"
+)
+
+type cart struct {
+	items []string
+}
+
+func (c *cart) count() int {
+	return len(c.items)
+}
+
+func messages(c *cart, name string, replace i18n4v.Replace, fallback string, scale float64) {
+	tr := i18n4v.Translate
+	tr("Save")
+	tr("_welcome", "Welcome")
+	tr("%n files", 3)
+	tr("%n items in cart", c.count())
+	tr("%n selected", len(c.items))
+	tr("Hi %{name}", replace)
+	tr("Hi %{name}", i18n4v.Replace{"name": name})
+	tr("_title", fallback)
+	tr("_title_with_count %n", fallback, len(c.items))
+	tr("Scale", scale)
+	tr("Hello", i18n4v.Replace{}, i18n4v.Context{"gender": "female"}) // i18n: greeting on the top page
+	billing := i18n4v.SelectTranslator("ja").Namespace("billing")
+	billing.Translate("Pay")
+}
+`
+
+func findUsage(usages []*usage, key string) *usage {
+	for _, found := range usages {
+		if found.key == key {
+			return found
+		}
+	}
+	return nil
+}
+
+func TestExtractGo(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"app/app.go": goSource})
+	defer os.RemoveAll(dir)
+	usages, err := extractGo([]string{filepath.Join(dir, "app/app.go")}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key         string
+		defaultText string
+		plural      bool
+	}{
+		{"Save", "", false},
+		{"_welcome", "Welcome", false},
+		{"%n files", "", true},
+		{"%n items in cart", "", true},
+		{"%n selected", "", true},
+		{"Hi %{name}", "", false},
+		{"_title", "", false},
+		{"_title_with_count %n", "", true},
+		{"Scale", "", false},
+	}
+	for _, test := range tests {
+		found := findUsage(usages, test.key)
+		if found == nil {
+			t.Errorf("It should extract '%s'", test.key)
+			continue
+		}
+		if found.defaultText != test.defaultText || found.plural != test.plural {
+			t.Errorf("'%s' should have default text '%s' and plural %v, but '%s' and %v", test.key, test.defaultText, test.plural, found.defaultText, found.plural)
+		}
+	}
+	for _, found := range usages {
+		if found.key == "Hi %{name}" && found.plural {
+			t.Error("Replace variable should not be treated as count")
+		}
+	}
+	hello := findUsage(usages, "Hello")
+	if hello == nil || !reflect.DeepEqual(hello.context, map[string]string{"gender": "female"}) || hello.comment != "greeting on the top page" {
+		t.Errorf("It should extract context and comment, but %+v", hello)
+	}
+	if pay := findUsage(usages, "Pay"); pay == nil || pay.namespace != "billing" {
+		t.Errorf("It should keep namespace of scoped translator, but %+v", pay)
+	}
+}
+
+func TestExtractGoIgnoresOtherTranslate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app/app.go": `package app
+
+import (
+	"github.com/shibukawa/i18n4v"
+)
+
+type client struct{}
+
+func (c *client) Translate(text string, lang string) string {
+	return text
+}
+
+type page struct {
+	tr *i18n4v.Translator
+}
+
+func messages(c *client, p *page, t *i18n4v.Translator) {
+	c.Translate("Cloud text", "ja")
+	p.tr.Translate("Field")
+	t.Translate("Parameter")
+	i18n4v.SelectTranslator("ja").Translate("Selected")
+}
+`,
+		"other/other.go": `package other
+
+type translator struct{}
+
+func (t translator) Translate(text string) string {
+	return text
+}
+
+func messages(t translator) {
+	t.Translate("Other package")
+}
+`,
+	})
+	defer os.RemoveAll(dir)
+	usages, err := extractGo([]string{filepath.Join(dir, "app/app.go"), filepath.Join(dir, "other/other.go")}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, found := range usages {
+		keys = append(keys, found.key)
+	}
+	if expected := []string{"Field", "Parameter", "Selected"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("It should extract only calls of i18n4v translators, but %v", keys)
+	}
+}
+
+func TestExtractPluralRanges(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"app/app.go": goSource})
+	defer os.RemoveAll(dir)
+	usages, err := extractGo([]string{filepath.Join(dir, "app/app.go")}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "en.json")
+	if err := extract(usages, output, true, false, false); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	problems, err := i18n4v.Validate(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Extracted dictionary should pass lint, but %v", problems)
+	}
+	if text := readFile(t, output); !strings.Contains(text, `"%n files": [`) {
+		t.Errorf("Plural key should be pluralisation, but\n%s", text)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"ja.json": `{
+    "values": {
+        "Save": "保存",
+        "Open": "",
+        "Close": "Close",
+        "Draft": "下書き"
+    },
+    "fuzzy": ["Draft"],
+    "namespaces": {
+        "billing": {"values": {"Pay": "支払う"}}
+    }
+}`,
+	})
+	defer os.RemoveAll(dir)
+	usages := []*usage{
+		{key: "Save", pkg: "app"},
+		{key: "Open", pkg: "app"},
+		{key: "Close", pkg: "app"},
+		{key: "Draft", pkg: "app"},
+		{key: "Save", pkg: "admin"},
+		{key: "Pay", namespace: "billing", pkg: "billing"},
+		{key: "Save", namespace: "billing", pkg: "billing"},
+	}
+	coverages, err := coverage(usages, []string{filepath.Join(dir, "ja.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := coverages[0]
+	if result.Locale != "ja" || result.Total != 6 || result.Translated != 3 {
+		t.Errorf("It should count translated keys, but %+v", result)
+	}
+	if len(result.Packages) != 3 || result.Packages[1].Package != "app" {
+		t.Fatalf("It should calculate coverage per package, but %+v", result.Packages)
+	}
+	app := result.Packages[1]
+	if app.Total != 4 || app.Translated != 1 || !reflect.DeepEqual(app.Missing, []string{"Close", "Draft", "Open"}) {
+		t.Errorf("Empty, copied and fuzzy translations should be missing, but %+v", app)
+	}
+	if billing := result.Packages[2]; billing.Total != 2 || billing.Translated != 2 {
+		t.Errorf("Keys in namespace should fall back to common namespace, but %+v", billing)
+	}
+}
+
+func TestCoverageWithUsageLog(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"ja.json": `{"values": {"Save": "保存", "Open": ""}}`,
+		"logs/usage.log": `{"locale": "ja", "key": "Save", "found": true}
+{"locale": "en", "key": "Save", "found": true}
+{"locale": "ja", "key": "Open", "found": false}
+`,
+	})
+	defer os.RemoveAll(dir)
+	usages, err := readUsageLogs([]string{filepath.Join(dir, "logs/usage.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	usages = append(usages, &usage{key: "Save", pkg: "app"})
+	coverages, err := coverage(usages, []string{filepath.Join(dir, "ja.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	packages := coverages[0].Packages
+	if len(packages) != 2 || packages[0].Package != runtimePackage || packages[1].Package != "app" {
+		t.Fatalf("Keys in usage log should be grouped in runtime package, but %+v", packages)
+	}
+	if packages[0].Total != 2 || packages[0].Translated != 1 || !reflect.DeepEqual(packages[0].Missing, []string{"Open"}) {
+		t.Errorf("It should calculate coverage of keys in usage log, but %+v", packages[0])
+	}
+	if coverages[0].Total != 2 {
+		t.Errorf("Keys in usage log and source files should be counted once, but %+v", coverages[0])
+	}
+}
+
+func TestCoverageSkipsDrafts(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"ja.json": `{
+    "values": {
+        "Save": "保存",
+        "Open": "開く",
+        "Close": "閉じる"
+    },
+    "fuzzy": ["Open"],
+    "meta": {
+        "Close": {"machine": "pseudo"}
+    },
+    "namespaces": {
+        "billing": {
+            "values": {"Close": "締める"},
+            "meta": {"Close": {"machine": "pseudo"}}
+        }
+    }
+}`,
+	})
+	defer os.RemoveAll(dir)
+	usages := []*usage{
+		{key: "Save", pkg: "app"},
+		{key: "Open", pkg: "app"},
+		{key: "Close", pkg: "app"},
+		{key: "Close", namespace: "billing", pkg: "billing"},
+	}
+	coverages, err := coverage(usages, []string{filepath.Join(dir, "ja.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := coverages[0].Packages[0]
+	if app.Translated != 1 || !reflect.DeepEqual(app.Missing, []string{"Close", "Open"}) {
+		t.Errorf("Fuzzy and machine drafts should be missing, but %+v", app)
+	}
+	if billing := coverages[0].Packages[1]; billing.Translated != 0 {
+		t.Errorf("Machine drafts in namespace should be missing, but %+v", billing)
+	}
+}
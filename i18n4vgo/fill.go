@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/shibukawa/i18n4v"
+	"golang.org/x/text/language"
+	"io"
+	"sort"
+)
+
+// providers are translation providers that fill command can use.
+// Machine translation services can be added here with the same interface.
+var providers = map[string]func() i18n4v.Provider{
+	"pseudo": func() i18n4v.Provider {
+		return &i18n4v.PseudoProvider{}
+	},
+}
+
+func providerNames() []string {
+	var result []string
+	for name := range providers {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// fillJob is an untranslated text in target dictionary.
+type fillJob struct {
+	entry *catalogEntry
+	// index is the index of pluralisation. It is -1 for plain translation.
+	index  int
+	source string
+}
+
+// fill translates untranslated entries in target dictionaries from the reference dictionary with provider.
+//
+// Keys that exist only in reference are added to targets. Filled keys have the provider name and
+// the source hash in meta, and they are marked as fuzzy if markFuzzy is true.
+// Fuzzy entries in targets are kept as is.
+func fill(reference string, targets []string, providerName, sourceLanguage string, markFuzzy bool, w io.Writer) error {
+	newProvider, ok := providers[providerName]
+	if !ok {
+		return fmt.Errorf("%s", tr("Unknown provider: %{name}", i18n4v.Replace{"name": providerName}))
+	}
+	provider := newProvider()
+	ref, err := loadDictionary(reference)
+	if err != nil {
+		return fmt.Errorf("%s: %v", reference, err)
+	}
+	if sourceLanguage == "" {
+		sourceLanguage = localeName(reference)
+	}
+	source, err := language.Parse(sourceLanguage)
+	if err != nil {
+		return fmt.Errorf("%s: %v", sourceLanguage, err)
+	}
+	refEntries := make(map[string]*catalogEntry)
+	for _, entry := range ref.entries() {
+		refEntries[entryID(entry)] = entry
+	}
+	for _, file := range targets {
+		target, err := language.Parse(localeName(file))
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		dict, err := loadDictionary(file)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		dict.addMissingEntries(ref)
+		var jobs []*fillJob
+		var texts []string
+		for _, entry := range dict.entries() {
+			if entry.fuzzy {
+				continue
+			}
+			refEntry := refEntries[entryID(entry)]
+			if refEntry == nil && len(entry.cases) > 0 {
+				// select cases of target language may not exist in reference
+				refEntry = refEntries[contextString(entry.namespace, entry.matches)+"\x00"+entry.key]
+			}
+			for i, text := range entry.texts {
+				if text != "" {
+					continue
+				}
+				job := &fillJob{entry: entry, index: -1, source: entry.key}
+				if entry.plural {
+					job.index = i
+					if refEntry != nil {
+						job.source = referenceText(refEntry, entry.bounds[i])
+					}
+				} else if refEntry != nil && refEntry.texts[0] != "" {
+					job.source = refEntry.texts[0]
+				}
+				jobs = append(jobs, job)
+				texts = append(texts, job.source)
+			}
+		}
+		if len(jobs) == 0 {
+			continue
+		}
+		translations, err := i18n4v.DraftTranslations(context.Background(), provider, source, target, texts)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		count := 0
+		for i, job := range jobs {
+			if translations[i] == "" {
+				continue
+			}
+			dict.setText(job.entry, job.index, translations[i])
+			meta := dict.meta(job.entry.namespace, job.entry.key)
+			meta.Machine = providerName
+			if sourceTexts := ref.sourceTexts(job.entry.namespace, job.entry.key); len(sourceTexts) > 0 {
+				meta.Source = i18n4v.SourceHash(sourceTexts)
+			}
+			if markFuzzy {
+				dict.markFuzzy(job.entry.namespace, job.entry.key, job.entry.matches, job.entry.cases)
+			}
+			count++
+		}
+		if err := dict.save(file); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, tr("%{file}: %{count} texts are filled by %{provider}.", i18n4v.Replace{"file": file, "count": count, "provider": providerName}))
+	}
+	return nil
+}
+
+func entryID(entry *catalogEntry) string {
+	return catalogContext(entry) + "\x00" + entry.key
+}
+
+// addMissingEntries adds entries that exist only in reference as untranslated entries.
+// Pluralisations get the same ranges and select forms get the same cases as reference.
+func (d *dictionary) addMissingEntries(reference *dictionary) {
+	existing := make(map[string]bool)
+	for _, entry := range d.entries() {
+		existing[contextString(entry.namespace, entry.matches)+"\x00"+entry.key] = true
+	}
+	for _, entry := range reference.entries() {
+		if existing[contextString(entry.namespace, entry.matches)+"\x00"+entry.key] {
+			continue
+		}
+		var value interface{} = ""
+		if entry.plural {
+			pluralisations := make([]interface{}, len(entry.bounds))
+			for i, bounds := range entry.bounds {
+				pluralisations[i] = []interface{}{bounds[0], bounds[1], ""}
+			}
+			value = pluralisations
+		}
+		d.addCaseIfNotExists(entry.namespace, entry.key, entry.cases, value, entry.matches)
+		dict := d.namespace(entry.namespace, true)
+		if entry.html && !containsString(dict.HTML, entry.key) {
+			dict.HTML = append(dict.HTML, entry.key)
+			sort.Strings(dict.HTML)
+		}
+	}
+}
+
+// setText sets translation text of the entry. index is the index of pluralisation or -1.
+func (d *dictionary) setText(entry *catalogEntry, index int, text string) {
+	dict := d.namespace(entry.namespace, false)
+	values := dict.Values
+	if context := dict.context(entry.matches, false); context != nil {
+		values = context.Values
+	}
+	values[entry.key] = updateCase(values[entry.key], entry.cases, func(value interface{}) interface{} {
+		if index < 0 {
+			return text
+		}
+		if pluralisations, ok := value.([]interface{}); ok && index < len(pluralisations) {
+			if spec, ok := pluralisations[index].([]interface{}); ok && len(spec) == 3 {
+				spec[2] = text
+			}
+		}
+		return value
+	})
+}
+
+// referenceText returns text of reference pluralisation that is used for the count range of target.
+// If reference doesn't have the text, it returns key.
+func referenceText(ref *catalogEntry, bounds [2]interface{}) string {
+	text := ""
+	if len(ref.texts) > 0 {
+		text = ref.texts[len(ref.texts)-1]
+	}
+	count, ok := boundValue(bounds[0])
+	if !ok {
+		count, ok = boundValue(bounds[1])
+	}
+	if ok && ref.plural {
+		for i, refBounds := range ref.bounds {
+			min, hasMin := boundValue(refBounds[0])
+			max, hasMax := boundValue(refBounds[1])
+			if (!hasMin || min <= count) && (!hasMax || count <= max) {
+				text = ref.texts[i]
+				break
+			}
+		}
+	}
+	if text == "" {
+		return ref.key
+	}
+	return text
+}
+
+// boundValue converts min or max of pluralisation. It returns false for null.
+func boundValue(bound interface{}) (int64, bool) {
+	switch v := bound.(type) {
+	case float64:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/shibukawa/i18n4v"
+	"golang.org/x/text/language"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pseudo returns the draft that fill command makes with pseudo provider.
+func pseudo(t *testing.T, text string) string {
+	result, err := i18n4v.DraftTranslations(context.Background(), &i18n4v.PseudoProvider{}, language.English, language.German, []string{text})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result[0]
+}
+
+func TestFill(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"en.json": `{
+    "values": {
+        "Save": "Save",
+        "_format": "Use {0} for %{name}",
+        "%n files": [[null, 0, "No files"], [1, 1, "%n file"], [2, null, "%n files"]],
+        "%n days": [[null, 0, "%n days"], [1, 1, "%n day"], [2, null, "%n days"]],
+        "%{name} replied": {
+            "select": "gender",
+            "cases": {"male": "%{name} replied to him", "other": "%{name} replied"}
+        }
+    }
+}`,
+		"de.json": `{
+    "values": {
+        "Save": "",
+        "%n files": [[null, 0, ""], [1, null, "%n Dateien"]]
+    }
+}`,
+	})
+	defer os.RemoveAll(dir)
+	de := filepath.Join(dir, "de.json")
+	var buffer bytes.Buffer
+	if err := fill(filepath.Join(dir, "en.json"), []string{de}, "pseudo", "", true, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	dict, err := loadDictionary(de)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dict.Values["Save"] != pseudo(t, "Save") || !dict.isFuzzy(nil, "Save") {
+		t.Errorf("Empty text should be filled and marked as fuzzy, but %v", dict.Values["Save"])
+	}
+	if text, _ := dict.Values["_format"].(string); !strings.Contains(text, "{0}") || !strings.Contains(text, "%{name}") {
+		t.Errorf("Literal token and placeholder should be kept, but %v", dict.Values["_format"])
+	}
+	files := dict.Values["%n files"].([]interface{})
+	if text := files[0].([]interface{})[2]; text != pseudo(t, "No files") {
+		t.Errorf("Pluralisation should be translated from the reference text of the same count, but %v", text)
+	}
+	if text := files[1].([]interface{})[2]; text != "%n Dateien" {
+		t.Errorf("Translated pluralisation should be kept, but %v", text)
+	}
+	days := dict.Values["%n days"].([]interface{})
+	if len(days) != 3 || days[1].([]interface{})[2] != pseudo(t, "%n day") {
+		t.Errorf("Missing pluralisation should be added with ranges of reference, but %v", days)
+	}
+	replied := dict.Values["%{name} replied"].(map[string]interface{})
+	cases := replied["cases"].(map[string]interface{})
+	if cases["male"] != pseudo(t, "%{name} replied to him") || cases["other"] != pseudo(t, "%{name} replied") {
+		t.Errorf("Missing select form should be added with cases of reference, but %v", cases)
+	}
+	if fuzzy := fuzzyCases(replied); !reflect.DeepEqual(fuzzy, []string{"male", "other"}) {
+		t.Errorf("Filled cases should be marked as fuzzy, but %v", fuzzy)
+	}
+	if meta := dict.Meta["Save"]; meta == nil || meta.Machine != "pseudo" || meta.Source == "" {
+		t.Errorf("Meta should have provider and source hash, but %+v", meta)
+	}
+}
+
+func TestReferenceText(t *testing.T) {
+	ref := &catalogEntry{
+		key:    "%n files",
+		plural: true,
+		bounds: [][2]interface{}{{nil, 0.0}, {1.0, 1.0}, {2.0, nil}},
+		texts:  []string{"No files", "%n file", ""},
+	}
+	tests := []struct {
+		bounds   [2]interface{}
+		expected string
+	}{
+		{[2]interface{}{nil, 0.0}, "No files"},
+		{[2]interface{}{1.0, 1.0}, "%n file"},
+		{[2]interface{}{1.0, nil}, "%n file"},
+		{[2]interface{}{5.0, nil}, "%n files"},
+	}
+	for _, test := range tests {
+		if text := referenceText(ref, test.bounds); text != test.expected {
+			t.Errorf("referenceText(%v) should be '%s', but '%s'", test.bounds, test.expected, text)
+		}
+	}
+}
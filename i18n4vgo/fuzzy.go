@@ -0,0 +1,124 @@
+package main
+
+import (
+	"sort"
+)
+
+// defaultFuzzyThreshold is the minimum similarity of keys to suggest existing translation.
+const defaultFuzzyThreshold = 0.75
+
+// editDistance returns Levenshtein distance of two strings in runes.
+func editDistance(a, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// similarity returns 1.0 for same strings and 0.0 for completely different strings.
+func similarity(a, b string) float64 {
+	length := len([]rune(a))
+	if l := len([]rune(b)); l > length {
+		length = l
+	}
+	if length == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(a, b))/float64(length)
+}
+
+// memoryEntry is a translated entry in the dictionary that can be reused for similar keys.
+type memoryEntry struct {
+	key   string
+	value interface{}
+}
+
+// translationMemory returns translated entries in root values, contexts and namespaces.
+// Empty translations, copies of keys and fuzzy entries are skipped.
+func (d *dictionary) translationMemory() []*memoryEntry {
+	var result []*memoryEntry
+	found := make(map[string]bool)
+	collect := func(values map[string]interface{}, fuzzy []string) {
+		for key, value := range values {
+			if found[key] || containsString(fuzzy, key) {
+				continue
+			}
+			for _, text := range translationTexts(value) {
+				if text != "" && text != key {
+					found[key] = true
+					result = append(result, &memoryEntry{key: key, value: value})
+					break
+				}
+			}
+		}
+	}
+	dicts := []*dictionary{d}
+	for _, name := range d.namespaceNames() {
+		dicts = append(dicts, d.Namespaces[name])
+	}
+	for _, dict := range dicts {
+		collect(dict.Values, dict.Fuzzy)
+		for _, context := range dict.Contexts {
+			collect(context.Values, context.Fuzzy)
+		}
+	}
+	// map order is random. Sort to make suggestions stable.
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].key < result[j].key
+	})
+	return result
+}
+
+// suggest returns translation of the most similar key in memory.
+// Plural keys reuse only pluralisations, and other keys reuse only strings. Select forms are not reused.
+func suggest(memory []*memoryEntry, key string, plural bool, threshold float64) (interface{}, bool) {
+	var best *memoryEntry
+	bestScore := threshold
+	length := len([]rune(key))
+	for _, entry := range memory {
+		_, isString := entry.value.(string)
+		_, isPlural := entry.value.([]interface{})
+		if !isString && !isPlural || isPlural != plural {
+			continue
+		}
+		// edit distance is at least the difference of lengths
+		entryLength := len([]rune(entry.key))
+		if entryLength < length && float64(entryLength) < float64(length)*bestScore ||
+			length < entryLength && float64(length) < float64(entryLength)*bestScore {
+			continue
+		}
+		if score := similarity(key, entry.key); score >= bestScore && (best == nil || score > bestScore) {
+			best = entry
+			bestScore = score
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return best.value, true
+}
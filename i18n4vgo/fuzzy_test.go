@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"Delete file", "Delete files", 1},
+		{"ファイル", "ファイルを削除", 3},
+	}
+	for _, test := range tests {
+		if d := editDistance(test.a, test.b); d != test.expected {
+			t.Errorf("editDistance(%q, %q) should be %d, but %d", test.a, test.b, test.expected, d)
+		}
+		if d := editDistance(test.b, test.a); d != test.expected {
+			t.Errorf("editDistance(%q, %q) should be %d, but %d", test.b, test.a, test.expected, d)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	plural := []interface{}{[]interface{}{1, 1, "%n ファイル"}}
+	memory := []*memoryEntry{
+		{key: "Delete file", value: "ファイルを削除"},
+		{key: "%n files", value: plural},
+		{key: "%{name} replied", value: map[string]interface{}{"select": "gender", "cases": map[string]interface{}{"other": "返信"}}},
+	}
+	if value, ok := suggest(memory, "Delete files", false, defaultFuzzyThreshold); !ok || value != "ファイルを削除" {
+		t.Errorf("It should suggest translation of similar key, but %v", value)
+	}
+	if _, ok := suggest(memory, "Open file", false, defaultFuzzyThreshold); ok {
+		t.Error("It should not suggest translation of different key")
+	}
+	if value, ok := suggest(memory, "%n file", true, defaultFuzzyThreshold); !ok || !reflect.DeepEqual(value, plural) {
+		t.Errorf("Plural key should reuse pluralisation, but %v", value)
+	}
+	if _, ok := suggest(memory, "%n file", false, defaultFuzzyThreshold); ok {
+		t.Error("Plain key should not reuse pluralisation")
+	}
+	if _, ok := suggest(memory, "%{name} replies", false, defaultFuzzyThreshold); ok {
+		t.Error("Select form should not be reused")
+	}
+}
+
+func TestExtractWithFuzzy(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"ja.json": `{
+    "values": {"Delete file": "ファイルを削除", "Draft": "下書き"},
+    "contexts": [
+        {"matches": {"gender": "female"}, "values": {"Delete folder": "フォルダを削除"}, "fuzzy": ["Delete folder"]}
+    ],
+    "fuzzy": ["Draft"]
+}`,
+	})
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "ja.json")
+	usages := []*usage{
+		{key: "Delete files"},
+		{key: "Drafts"},
+		{key: "Delete folders"},
+		{key: "Delete file", context: map[string]string{"gender": "female"}},
+	}
+	if err := extract(usages, output, false, true, false); err != nil {
+		t.Fatal(err)
+	}
+	dict, err := loadDictionary(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dict.Values["Delete files"] != "ファイルを削除" || !dict.isFuzzy(nil, "Delete files") {
+		t.Errorf("Similar key should get fuzzy draft, but %v", dict.Values["Delete files"])
+	}
+	if dict.Values["Drafts"] != "" || dict.isFuzzy(nil, "Drafts") {
+		t.Errorf("Fuzzy translation should not be suggested, but %v", dict.Values["Drafts"])
+	}
+	if dict.Values["Delete folders"] != "" {
+		t.Errorf("Fuzzy translation in context should not be suggested, but %v", dict.Values["Delete folders"])
+	}
+	female := map[string]string{"gender": "female"}
+	if dict.context(female, false).Values["Delete file"] != "ファイルを削除" || !dict.isFuzzy(female, "Delete file") || dict.isFuzzy(nil, "Delete file") {
+		t.Errorf("Draft in context should be marked as fuzzy only in the context, but %v, %v", dict.Fuzzy, dict.context(female, false).Fuzzy)
+	}
+}
+
+func TestMarkFuzzyCase(t *testing.T) {
+	dict := &dictionary{Values: map[string]interface{}{
+		"%{name} replied": map[string]interface{}{
+			"select": "gender",
+			"cases": map[string]interface{}{
+				"female": map[string]interface{}{
+					"select": "case",
+					"cases":  map[string]interface{}{"dative": "ihr", "other": "sie"},
+				},
+				"other": "er",
+			},
+		},
+	}}
+	dict.markFuzzy("", "%{name} replied", nil, []string{"gender=other"})
+	dict.markFuzzy("", "%{name} replied", nil, []string{"gender=female", "case=dative"})
+	form := dict.Values["%{name} replied"].(map[string]interface{})
+	if fuzzy := fuzzyCases(form); !reflect.DeepEqual(fuzzy, []string{"other"}) {
+		t.Errorf("Case name should be marked as fuzzy, but %v", fuzzy)
+	}
+	nested := form["cases"].(map[string]interface{})["female"].(map[string]interface{})
+	if fuzzy := fuzzyCases(nested); !reflect.DeepEqual(fuzzy, []string{"dative"}) {
+		t.Errorf("Case name of nested select form should be marked as fuzzy, but %v", fuzzy)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/shibukawa/i18n4v"
+	"os"
+)
+
+// lint validates dictionary files and returns exit code.
+// It returns 1 if any problems are found.
+func lint(files []string, format string) int {
+	problems := []*i18n4v.Problem{}
+	for _, file := range files {
+		found, err := validateFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr("%{file}: %{error}", i18n4v.Replace{"file": file, "error": err}))
+			return 2
+		}
+		problems = append(problems, found...)
+	}
+	return report(problems, format)
+}
+
+// report writes problems in specified format and returns exit code.
+func report(problems []*i18n4v.Problem, format string) int {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "    ")
+		enc.Encode(problems)
+	default:
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func validateFile(file string) ([]*i18n4v.Problem, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	problems, err := i18n4v.Validate(f)
+	if err != nil {
+		return nil, err
+	}
+	for _, problem := range problems {
+		problem.File = file
+	}
+	return problems, nil
+}
@@ -1,30 +1,280 @@
 package main
 
 import (
-	"go/parser"
-	"gopkg.in/alecthomas/kingpin.v2"
+	"fmt"
 	"github.com/shibukawa/i18n4v"
-	"path"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"os"
+	"regexp"
+	"strings"
 )
 
 var tr i18n4v.TranslatorFunction
 
+var extractCommand *kingpin.CmdClause
 var output *string
-var goExclude *string
+var extractSource *sourceOptions
 var fillCopy *bool
+var fuzzy *bool
+var refs *bool
 var inputPaths *[]string
 
+var lintCommand *kingpin.CmdClause
+var lintFormat *string
+var lintFiles *[]string
+
+var compareCommand *kingpin.CmdClause
+var compareFormat *string
+var compareReference *string
+var compareTargets *[]string
+
+var coverageCommand *kingpin.CmdClause
+var coverageFormat *string
+var coverageOutput *string
+var coverageLocales *[]string
+var coverageSource *sourceOptions
+var coverageInputs *[]string
+
+var exportCommand *kingpin.CmdClause
+var exportFormat *string
+var exportOutput *string
+var exportLocale *string
+var exportSourceLanguage *string
+var exportInput *string
+
+var importCommand *kingpin.CmdClause
+var importFormat *string
+var importOutput *string
+var importInput *string
+
+var pruneCommand *kingpin.CmdClause
+var pruneLocales *[]string
+var pruneJSDictionaries *[]string
+var pruneObsolete *bool
+var pruneDryRun *bool
+var pruneSource *sourceOptions
+var pruneInputs *[]string
+
+var fillCommand *kingpin.CmdClause
+var fillProvider *string
+var fillSourceLanguage *string
+var fillFuzzy *bool
+var fillReference *string
+var fillTargets *[]string
+
+var stampCommand *kingpin.CmdClause
+var stampAll *bool
+var stampReference *string
+var stampTargets *[]string
+
 func init() {
-	tr = i18n4v.Select("en")
+	tr = i18n4v.Translate
+
+	extractCommand = kingpin.Command("extract", tr("Extract translation keys from source files.")).Default()
+	output = extractCommand.Flag("output", tr("Output file path. You can add extension .js/.json.")).Short('o').Required().String()
+	extractSource = addSourceFlags(extractCommand)
+	fillCopy = extractCommand.Flag("fill-copy", tr("Fill key as default translation text")).Default("false").Bool()
+	fuzzy = extractCommand.Flag("fuzzy", tr("Suggest translations of similar keys as fuzzy drafts.")).Default("true").Bool()
+	refs = extractCommand.Flag("refs", tr("Record source references and i18n: comments in meta section.")).Default("true").Bool()
+	inputPaths = extractCommand.Arg("inputs", tr("source files/dirs...")).Required().ExistingFilesOrDirs()
+
+	lintCommand = kingpin.Command("lint", tr("Validate dictionary files."))
+	lintFormat = lintCommand.Flag("format", tr("Output format (text or json).")).Default("text").Enum("text", "json")
+	lintFiles = lintCommand.Arg("files", tr("dictionary files...")).Required().ExistingFiles()
+
+	compareCommand = kingpin.Command("compare", tr("Compare dictionary files with reference dictionary."))
+	compareFormat = compareCommand.Flag("format", tr("Output format (text or json).")).Default("text").Enum("text", "json")
+	compareReference = compareCommand.Arg("reference", tr("reference dictionary file (like en.json)")).Required().ExistingFile()
+	compareTargets = compareCommand.Arg("targets", tr("dictionary files to compare...")).Required().ExistingFiles()
+
+	coverageCommand = kingpin.Command("coverage", tr("Report translation coverage per locale and package."))
+	coverageFormat = coverageCommand.Flag("format", tr("Output format (text, json or html).")).Default("text").Enum("text", "json", "html")
+	coverageOutput = coverageCommand.Flag("output", tr("Output file path. Default is stdout.")).Short('o').String()
+	coverageLocales = coverageCommand.Flag("locale", tr("Dictionary file of a locale (like ja.json).")).Short('l').Required().ExistingFiles()
+	coverageSource = addSourceFlags(coverageCommand)
+	coverageInputs = coverageCommand.Arg("inputs", tr("source files/dirs...")).Required().ExistingFilesOrDirs()
 
-	output = kingpin.Flag("output", tr("Output file path. You can add extension .js/.json.")).Short('o').String()
-	goExclude = kingpin.Flag("go-exclude", tr("Input JavaScript file filtering pattern.")).String()
-	fillCopy = kingpin.Flag("fill-copy", tr("Fill key as default translation text")).Default("false").Bool()
-	inputPaths = kingpin.Arg("inputs", tr("source files/dirs...")).Required().ExistingFilesOrDirs()
+	exportCommand = kingpin.Command("export", tr("Convert dictionary file into PO or XLIFF file for translators."))
+	exportFormat = exportCommand.Flag("format", tr("Output format (po or xliff). Default is decided by extension of output.")).Enum("po", "xliff")
+	exportOutput = exportCommand.Flag("output", tr("Output file path. Default is stdout.")).Short('o').String()
+	exportLocale = exportCommand.Flag("locale", tr("Locale of the dictionary. Default is the file name.")).Short('l').String()
+	exportSourceLanguage = exportCommand.Flag("source-language", tr("Language of keys.")).Default("en").String()
+	exportInput = exportCommand.Arg("input", tr("dictionary file (like ja.json)")).Required().ExistingFile()
+
+	importCommand = kingpin.Command("import", tr("Convert PO or XLIFF file into dictionary file."))
+	importFormat = importCommand.Flag("format", tr("Input format (po or xliff). Default is decided by extension of input.")).Enum("po", "xliff")
+	importOutput = importCommand.Flag("output", tr("Output dictionary file path.")).Short('o').Required().String()
+	importInput = importCommand.Arg("input", tr("PO or XLIFF file")).Required().ExistingFile()
+
+	pruneCommand = kingpin.Command("prune", tr("Remove keys that are not used in source files from dictionary files."))
+	pruneLocales = pruneCommand.Flag("locale", tr("Dictionary file of a locale (like ja.json).")).Short('l').Required().ExistingFiles()
+	pruneJSDictionaries = pruneCommand.Flag("js-dict", tr("Dictionary file that JavaScript extractor (i18n4v command) outputs. Its keys are treated as used.")).ExistingFiles()
+	pruneObsolete = pruneCommand.Flag("obsolete", tr("Move unused keys to obsolete section instead of removing them.")).Default("false").Bool()
+	pruneDryRun = pruneCommand.Flag("dry-run", tr("Show changes in unified diff format without modifying files.")).Short('n').Default("false").Bool()
+	pruneSource = addSourceFlags(pruneCommand)
+	pruneInputs = pruneCommand.Arg("inputs", tr("source files/dirs...")).ExistingFilesOrDirs()
+
+	fillCommand = kingpin.Command("fill", tr("Fill untranslated keys with draft translations of reference dictionary."))
+	fillProvider = fillCommand.Flag("provider", tr("Translation provider.")).Default("pseudo").Enum(providerNames()...)
+	fillSourceLanguage = fillCommand.Flag("source-language", tr("Language of reference dictionary. Default is the file name.")).String()
+	fillFuzzy = fillCommand.Flag("fuzzy", tr("Mark filled keys as fuzzy so that they are not used until reviewed.")).Default("true").Bool()
+	fillReference = fillCommand.Arg("reference", tr("reference dictionary file (like en.json)")).Required().ExistingFile()
+	fillTargets = fillCommand.Arg("targets", tr("dictionary files to fill (like ja.json)...")).Required().ExistingFiles()
+
+	stampCommand = kingpin.Command("stamp", tr("Record hashes of reference texts in translated keys to find stale translations with compare command."))
+	stampAll = stampCommand.Flag("all", tr("Update existing hashes too. Use it after reviewing stale translations.")).Default("false").Bool()
+	stampReference = stampCommand.Arg("reference", tr("reference dictionary file (like en.json)")).Required().ExistingFile()
+	stampTargets = stampCommand.Arg("targets", tr("translated dictionary files (like ja.json)...")).Required().ExistingFiles()
 }
 
 const version = "0.3.1"
 
 func main() {
-	kingpin.Parse()
+	kingpin.Version(version)
+	switch kingpin.Parse() {
+	case extractCommand.FullCommand():
+		usages, err := findUsages(*inputPaths, extractSource)
+		kingpin.FatalIfError(err, "")
+		kingpin.FatalIfError(extract(usages, *output, *fillCopy, *fuzzy, *refs), "")
+	case lintCommand.FullCommand():
+		os.Exit(lint(*lintFiles, *lintFormat))
+	case compareCommand.FullCommand():
+		os.Exit(compare(*compareReference, *compareTargets, *compareFormat))
+	case coverageCommand.FullCommand():
+		usages, err := findUsages(*coverageInputs, coverageSource)
+		kingpin.FatalIfError(err, "")
+		coverages, err := coverage(usages, *coverageLocales)
+		kingpin.FatalIfError(err, "")
+		w := os.Stdout
+		if *coverageOutput != "" {
+			w, err = os.Create(*coverageOutput)
+			kingpin.FatalIfError(err, "")
+			defer w.Close()
+		}
+		kingpin.FatalIfError(writeCoverage(w, coverages, *coverageFormat), "")
+	case exportCommand.FullCommand():
+		kingpin.FatalIfError(exportDictionary(*exportInput, *exportOutput, *exportFormat, *exportLocale, *exportSourceLanguage), "")
+	case importCommand.FullCommand():
+		kingpin.FatalIfError(importDictionary(*importInput, *importOutput, *importFormat), "")
+	case pruneCommand.FullCommand():
+		usages, err := findUsages(*pruneInputs, pruneSource)
+		kingpin.FatalIfError(err, "")
+		kingpin.FatalIfError(prune(usages, *pruneJSDictionaries, *pruneLocales, *pruneObsolete, *pruneDryRun, os.Stdout), "")
+	case fillCommand.FullCommand():
+		kingpin.FatalIfError(fill(*fillReference, *fillTargets, *fillProvider, *fillSourceLanguage, *fillFuzzy, os.Stdout), "")
+	case stampCommand.FullCommand():
+		kingpin.FatalIfError(stamp(*stampReference, *stampTargets, *stampAll, os.Stdout), "")
+	}
+}
+
+// sourceOptions keeps options to find translation keys in source files.
+type sourceOptions struct {
+	exclude             *string
+	goExclude           *string
+	funcs               *[]string
+	tags                *[]string
+	templateExts        *[]string
+	templateFuncs       *[]string
+	templatePluralFuncs *[]string
+	usageLogs           *[]string
+}
+
+// addSourceFlags adds flags for finding translation keys to the command.
+func addSourceFlags(command *kingpin.CmdClause) *sourceOptions {
+	return &sourceOptions{
+		exclude:             command.Flag("exclude", tr("Input Go and template file filtering pattern.")).String(),
+		goExclude:           command.Flag("go-exclude", tr("Deprecated alias of --exclude.")).Hidden().String(),
+		funcs:               command.Flag("func", tr("Additional translation function name.")).Strings(),
+		tags:                command.Flag("tag", tr("Struct tag name that has translation key (like msg).")).Strings(),
+		templateExts:        command.Flag("template-ext", tr("Extension of template files.")).Default(".tmpl").Strings(),
+		templateFuncs:       command.Flag("template-func", tr("Translation function name in templates.")).Default("t").Strings(),
+		templatePluralFuncs: command.Flag("template-plural-func", tr("Translation function name with count in templates.")).Default("tn").Strings(),
+		usageLogs:           command.Flag("usage-log", tr("Key usage log that is recorded at runtime (i18n4v.UsageLog). Its keys are treated as used.")).ExistingFiles(),
+	}
+}
+
+// findUsages extracts translation keys from source files/dirs.
+// Keys in Go files, template files, struct tags and usage logs are merged.
+func findUsages(inputs []string, options *sourceOptions) ([]*usage, error) {
+	exclude, option := *options.exclude, "--exclude"
+	if exclude == "" && *options.goExclude != "" {
+		exclude, option = *options.goExclude, "--go-exclude"
+	}
+	var excludePattern *regexp.Regexp
+	if exclude != "" {
+		var err error
+		excludePattern, err = regexp.Compile(exclude)
+		if err != nil {
+			return nil, fmt.Errorf("%s%v", tr("Parse error at %{opt}: ", i18n4v.Replace{"opt": option}), err)
+		}
+	}
+	goFiles, templateFiles, err := findSourceFiles(inputs, excludePattern, *options.templateExts)
+	if err != nil {
+		return nil, err
+	}
+	result, err := extractGo(goFiles, *options.funcs, *options.tags)
+	if err != nil {
+		return nil, err
+	}
+	usages, err := extractTemplates(templateFiles, newTemplateFunctions(*options.templateFuncs, *options.templatePluralFuncs))
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, usages...)
+	usages, err = readUsageLogs(*options.usageLogs)
+	if err != nil {
+		return nil, err
+	}
+	return append(result, usages...), nil
+}
+
+// extract adds found keys to output dictionary.
+// If fuzzy is true, new keys that are similar to translated keys get their translations as fuzzy drafts.
+// If refs is true, source references and comments of found keys are updated.
+func extract(usages []*usage, output string, fillCopy, fuzzy, refs bool) error {
+	dict, err := loadOrCreateDictionary(output)
+	if err != nil {
+		return err
+	}
+	var memory []*memoryEntry
+	if fuzzy {
+		memory = dict.translationMemory()
+	}
+	if refs {
+		updateMeta(dict, usages)
+	}
+	for _, found := range usages {
+		if dict.has(found.namespace, found.key, found.context) {
+			continue
+		}
+		value, suggested := suggest(memory, found.key, found.plural, defaultFuzzyThreshold)
+		if !suggested {
+			value = initialValue(found, fillCopy)
+		}
+		dict.addIfNotExists(found.namespace, found.key, value, found.context)
+		if suggested {
+			dict.markFuzzy(found.namespace, found.key, found.context, nil)
+		}
+	}
+	return dict.save(output)
+}
+
+// updateMeta replaces source references and comments of found keys.
+func updateMeta(dict *dictionary, usages []*usage) {
+	updated := make(map[*keyMeta]bool)
+	for _, found := range usages {
+		meta := dict.meta(found.namespace, found.key)
+		if !updated[meta] {
+			updated[meta] = true
+			meta.Refs = nil
+			meta.Comment = ""
+		}
+		meta.Refs = append(meta.Refs, found.ref())
+		if found.comment != "" && !strings.Contains(meta.Comment, found.comment) {
+			if meta.Comment != "" {
+				meta.Comment += "\n"
+			}
+			meta.Comment += found.comment
+		}
+	}
 }
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Flags in PO file. fuzzy is the standard one. Others keep information of the JSON dictionary.
+const (
+	poFuzzyFlag = "fuzzy"
+	poHTMLFlag  = "i18n4v-html"
+	// poRangePrefix is a prefix of flags that keep pluralisation ranges like "range:1..1".
+	poRangePrefix = "range:"
+)
+
+var poEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+
+func poQuote(text string) string {
+	return `"` + poEscaper.Replace(text) + `"`
+}
+
+// poUnquote decodes a string in PO file. It accepts C escape sequences that gettext tools accept
+// like \' and octal/hex escapes that strconv.Unquote rejects in double quoted strings.
+func poUnquote(value string) (string, error) {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return "", fmt.Errorf("string should be quoted")
+	}
+	value = value[1 : len(value)-1]
+	var result strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '"' {
+			return "", fmt.Errorf("unescaped quote")
+		}
+		if c != '\\' {
+			result.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(value) {
+			return "", fmt.Errorf("incomplete escape sequence")
+		}
+		switch c = value[i]; c {
+		case 'n':
+			result.WriteByte('\n')
+		case 't':
+			result.WriteByte('\t')
+		case 'r':
+			result.WriteByte('\r')
+		case 'a':
+			result.WriteByte('\a')
+		case 'b':
+			result.WriteByte('\b')
+		case 'f':
+			result.WriteByte('\f')
+		case 'v':
+			result.WriteByte('\v')
+		case '\\', '"', '\'', '?':
+			result.WriteByte(c)
+		case 'x':
+			end := i + 1
+			for end < len(value) && end < i+3 && strings.IndexByte("0123456789abcdefABCDEF", value[end]) != -1 {
+				end++
+			}
+			code, err := strconv.ParseUint(value[i+1:end], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid hex escape sequence")
+			}
+			result.WriteByte(byte(code))
+			i = end - 1
+		default:
+			end := i
+			for end < len(value) && end < i+3 && value[end] >= '0' && value[end] <= '7' {
+				end++
+			}
+			code, err := strconv.ParseUint(value[i:end], 8, 8)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape sequence \\%c", c)
+			}
+			result.WriteByte(byte(code))
+			i = end - 1
+		}
+	}
+	return result.String(), nil
+}
+
+// pluralForms is the partition of counts that separates pluralisation ranges of all entries.
+// PO file has only one set of plural forms (Plural-Forms header), so each pluralisation is written in these forms.
+type pluralForms struct {
+	// bounds keeps min and max counts of forms in order. math.MinInt64 and math.MaxInt64 mean null.
+	bounds [][2]int64
+}
+
+// countRange returns min and max of pluralisation range. null becomes math.MinInt64 or math.MaxInt64.
+func countRange(bounds [2]interface{}) (int64, int64) {
+	min, ok := boundValue(bounds[0])
+	if !ok {
+		min = math.MinInt64
+	}
+	max, ok := boundValue(bounds[1])
+	if !ok {
+		max = math.MaxInt64
+	}
+	return min, max
+}
+
+// newPluralForms splits counts at each bound of pluralisation ranges in entries.
+// Counts that no range covers are merged into the next form (or the previous one at the end).
+func newPluralForms(entries []*catalogEntry) *pluralForms {
+	var ranges [][2]int64
+	startSet := make(map[int64]bool)
+	for _, entry := range entries {
+		if !entry.plural {
+			continue
+		}
+		for _, bounds := range entry.bounds {
+			min, max := countRange(bounds)
+			ranges = append(ranges, [2]int64{min, max})
+			if min != math.MinInt64 {
+				startSet[min] = true
+			}
+			if max != math.MaxInt64 {
+				startSet[max+1] = true
+			}
+		}
+	}
+	starts := make([]int64, 0, len(startSet))
+	for start := range startSet {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	covered := func(min, max int64) bool {
+		for _, r := range ranges {
+			if r[0] <= max && min <= r[1] {
+				return true
+			}
+		}
+		return false
+	}
+	result := &pluralForms{}
+	pending, hasPending := int64(0), false
+	for i := 0; i <= len(starts); i++ {
+		min, max := int64(math.MinInt64), int64(math.MaxInt64)
+		if i > 0 {
+			min = starts[i-1]
+		}
+		if i < len(starts) {
+			max = starts[i] - 1
+		}
+		if !covered(min, max) {
+			if !hasPending {
+				pending, hasPending = min, true
+			}
+			continue
+		}
+		if hasPending {
+			min, hasPending = pending, false
+		}
+		result.bounds = append(result.bounds, [2]int64{min, max})
+	}
+	if len(result.bounds) == 0 {
+		result.bounds = [][2]int64{{math.MinInt64, math.MaxInt64}}
+	} else if hasPending {
+		result.bounds[len(result.bounds)-1][1] = math.MaxInt64
+	}
+	return result
+}
+
+// header returns the value of Plural-Forms header like "nplurals=3; plural=(n<=0 ? 0 : n<=1 ? 1 : 2);".
+// n is unsigned in gettext, so forms of negative counts have condition that is always false.
+func (f *pluralForms) header() string {
+	last := len(f.bounds) - 1
+	var expression strings.Builder
+	for i, bounds := range f.bounds[:last] {
+		if bounds[1] < 0 {
+			expression.WriteString("0")
+		} else {
+			fmt.Fprintf(&expression, "n<=%d", bounds[1])
+		}
+		fmt.Fprintf(&expression, " ? %d : ", i)
+	}
+	fmt.Fprintf(&expression, "%d", last)
+	return fmt.Sprintf("nplurals=%d; plural=(%s);", len(f.bounds), expression.String())
+}
+
+// index returns the form that the pluralisation range belongs to.
+func (f *pluralForms) index(bounds [2]interface{}) int {
+	min, max := countRange(bounds)
+	for i, form := range f.bounds {
+		if form[0] <= max && min <= form[1] {
+			return i
+		}
+	}
+	return 0
+}
+
+// texts returns translations of entry in order of the forms. Forms that entry doesn't cover are empty.
+func (f *pluralForms) texts(entry *catalogEntry) []string {
+	result := make([]string, len(f.bounds))
+	for i := len(entry.bounds) - 1; i >= 0; i-- {
+		if i < len(entry.texts) {
+			min, max := countRange(entry.bounds[i])
+			for j, form := range f.bounds {
+				if form[0] <= max && min <= form[1] {
+					result[j] = entry.texts[i]
+				}
+			}
+		}
+	}
+	return result
+}
+
+// writePO writes entries in gettext PO format.
+//
+// Namespaces and contexts are written as msgctxt like "billing{plan=free}".
+// Pluralisations are written with msgid_plural in forms of Plural-Forms header (see pluralForms),
+// and their ranges are kept in flags like "range:2..".
+func writePO(w io.Writer, entries []*catalogEntry, locale string) error {
+	forms := newPluralForms(entries)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, `msgid ""`)
+	fmt.Fprintln(bw, `msgstr ""`)
+	fmt.Fprintln(bw, poQuote("Language: "+locale+"\n"))
+	fmt.Fprintln(bw, poQuote("MIME-Version: 1.0\n"))
+	fmt.Fprintln(bw, poQuote("Content-Type: text/plain; charset=UTF-8\n"))
+	fmt.Fprintln(bw, poQuote("Content-Transfer-Encoding: 8bit\n"))
+	fmt.Fprintln(bw, poQuote("Plural-Forms: "+forms.header()+"\n"))
+	fmt.Fprintln(bw, poQuote("X-Generator: i18n4vgo "+version+"\n"))
+	for _, entry := range entries {
+		fmt.Fprintln(bw)
+		if entry.comment != "" {
+			for _, line := range strings.Split(entry.comment, "\n") {
+				fmt.Fprintln(bw, "#. "+line)
+			}
+		}
+		for _, ref := range entry.refs {
+			fmt.Fprintln(bw, "#: "+ref)
+		}
+		var flags []string
+		if entry.fuzzy {
+			flags = append(flags, poFuzzyFlag)
+		}
+		if entry.html {
+			flags = append(flags, poHTMLFlag)
+		}
+		for _, bounds := range entry.bounds {
+			flags = append(flags, poRangePrefix+formatRange(bounds))
+		}
+		if len(flags) > 0 {
+			fmt.Fprintln(bw, "#, "+strings.Join(flags, ", "))
+		}
+		if context := catalogContext(entry); context != "" {
+			fmt.Fprintln(bw, "msgctxt "+poQuote(context))
+		}
+		fmt.Fprintln(bw, "msgid "+poQuote(entry.key))
+		if entry.plural {
+			fmt.Fprintln(bw, "msgid_plural "+poQuote(entry.key))
+			for i, text := range forms.texts(entry) {
+				fmt.Fprintf(bw, "msgstr[%d] %s\n", i, poQuote(text))
+			}
+		} else {
+			fmt.Fprintln(bw, "msgstr "+poQuote(entry.texts[0]))
+		}
+	}
+	return bw.Flush()
+}
+
+// poEntry keeps fields of an entry during parsing.
+type poEntry struct {
+	comments    []string
+	refs        []string
+	flags       []string
+	context     string
+	id          string
+	hasID       bool
+	plural      bool
+	translation map[int]string
+}
+
+func (e *poEntry) catalogEntry() (*catalogEntry, error) {
+	namespace, matches, cases := parseCatalogContext(e.context)
+	result := &catalogEntry{
+		namespace: namespace,
+		matches:   matches,
+		key:       e.id,
+		cases:     cases,
+		plural:    e.plural,
+		comment:   strings.Join(e.comments, "\n"),
+		refs:      e.refs,
+	}
+	for _, flag := range e.flags {
+		switch {
+		case flag == poFuzzyFlag:
+			result.fuzzy = true
+		case flag == poHTMLFlag:
+			result.html = true
+		case strings.HasPrefix(flag, poRangePrefix):
+			bounds, err := parseRange(strings.TrimPrefix(flag, poRangePrefix))
+			if err != nil {
+				return nil, err
+			}
+			result.bounds = append(result.bounds, bounds)
+		}
+	}
+	indexes := make([]int, 0, len(e.translation))
+	for index := range e.translation {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		result.texts = append(result.texts, e.translation[index])
+	}
+	return result, nil
+}
+
+// readPO reads entries from gettext PO format that writePO writes.
+// Header entry and obsolete entries are skipped.
+//
+// If the file has Plural-Forms header, translations of pluralisations are read from the forms
+// that their ranges belong to (see pluralForms). Otherwise they are read in order of the ranges.
+func readPO(r io.Reader) ([]*catalogEntry, error) {
+	var result []*catalogEntry
+	var parsed []*poEntry
+	var header string
+	current := &poEntry{translation: make(map[int]string)}
+	// appendTo keeps the field that continuation lines are added to.
+	var appendTo func(text string)
+	flush := func() error {
+		if current.hasID && current.id != "" {
+			entry, err := current.catalogEntry()
+			if err != nil {
+				return err
+			}
+			result = append(result, entry)
+			parsed = append(parsed, current)
+		} else if current.hasID && current.context == "" {
+			header = current.translation[0]
+		}
+		current = &poEntry{translation: make(map[int]string)}
+		appendTo = nil
+		return nil
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		// comments after msgstr start a new entry
+		if strings.HasPrefix(line, "#") && len(current.translation) > 0 {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		var keyword, value string
+		switch {
+		case line == "":
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		case strings.HasPrefix(line, "#~"):
+			continue
+		case strings.HasPrefix(line, "#."):
+			current.comments = append(current.comments, strings.TrimSpace(line[2:]))
+			continue
+		case strings.HasPrefix(line, "#:"):
+			current.refs = append(current.refs, strings.Fields(line[2:])...)
+			continue
+		case strings.HasPrefix(line, "#,"):
+			for _, flag := range strings.Split(line[2:], ",") {
+				current.flags = append(current.flags, strings.TrimSpace(flag))
+			}
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, `"`):
+			keyword, value = "", line
+		default:
+			index := strings.Index(line, " ")
+			if index == -1 {
+				return nil, fmt.Errorf("line %d: invalid line '%s'", lineNumber, line)
+			}
+			keyword, value = line[:index], strings.TrimSpace(line[index+1:])
+		}
+		text, err := poUnquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid string %s: %v", lineNumber, value, err)
+		}
+		switch {
+		case keyword == "":
+			if appendTo == nil {
+				return nil, fmt.Errorf("line %d: unexpected string", lineNumber)
+			}
+			appendTo(text)
+			continue
+		case keyword == "msgctxt":
+			if current.hasID {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			current.context = text
+			appendTo = func(text string) { current.context += text }
+		case keyword == "msgid":
+			if current.hasID {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			current.id = text
+			current.hasID = true
+			appendTo = func(text string) { current.id += text }
+		case keyword == "msgid_plural":
+			current.plural = true
+			appendTo = func(string) {}
+		case keyword == "msgstr":
+			current.translation[0] = text
+			appendTo = func(text string) { current.translation[0] += text }
+		case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+			index, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid keyword '%s'", lineNumber, keyword)
+			}
+			current.translation[index] = text
+			appendTo = func(text string) { current.translation[index] += text }
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword '%s'", lineNumber, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if hasPluralForms(header) {
+		forms := newPluralForms(result)
+		for i, entry := range result {
+			if !entry.plural || len(entry.bounds) == 0 {
+				continue
+			}
+			entry.texts = make([]string, len(entry.bounds))
+			for j, bounds := range entry.bounds {
+				entry.texts[j] = parsed[i].translation[forms.index(bounds)]
+			}
+		}
+	}
+	return result, nil
+}
+
+// hasPluralForms checks whether the header entry of PO file has Plural-Forms.
+func hasPluralForms(header string) bool {
+	for _, line := range strings.Split(header, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "Plural-Forms:") {
+			return true
+		}
+	}
+	return false
+}
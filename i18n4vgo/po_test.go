@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPluralForms(t *testing.T) {
+	tests := []struct {
+		bounds   [][][2]interface{}
+		expected string
+	}{
+		{nil, "nplurals=1; plural=(0);"},
+		{[][][2]interface{}{{{nil, 0.0}, {1.0, 1.0}, {2.0, nil}}}, "nplurals=3; plural=(n<=0 ? 0 : n<=1 ? 1 : 2);"},
+		{[][][2]interface{}{{{nil, 0.0}, {1.0, nil}}, {{1.0, 1.0}, {2.0, nil}}}, "nplurals=3; plural=(n<=0 ? 0 : n<=1 ? 1 : 2);"},
+		// counts that no range covers are merged into the next form
+		{[][][2]interface{}{{{1.0, 1.0}, {5.0, nil}}}, "nplurals=2; plural=(n<=1 ? 0 : 1);"},
+		{[][][2]interface{}{{{nil, -1.0}, {0.0, nil}}}, "nplurals=2; plural=(0 ? 0 : 1);"},
+	}
+	for _, test := range tests {
+		var entries []*catalogEntry
+		for _, bounds := range test.bounds {
+			entries = append(entries, &catalogEntry{plural: true, bounds: bounds})
+		}
+		if header := newPluralForms(entries).header(); header != test.expected {
+			t.Errorf("Plural-Forms of %v should be '%s', but '%s'", test.bounds, test.expected, header)
+		}
+	}
+}
+
+func TestWritePOPluralForms(t *testing.T) {
+	entries := []*catalogEntry{
+		{key: "%n files", plural: true, bounds: [][2]interface{}{{nil, 0.0}, {1.0, nil}}, texts: []string{"none", "%n files"}},
+		{key: "%n items", plural: true, bounds: [][2]interface{}{{1.0, 1.0}, {2.0, nil}}, texts: []string{"one", "%n items"}},
+	}
+	var buffer bytes.Buffer
+	if err := writePO(&buffer, entries, "en"); err != nil {
+		t.Fatal(err)
+	}
+	po := buffer.String()
+	for _, expected := range []string{
+		`"Plural-Forms: nplurals=3; plural=(n<=0 ? 0 : n<=1 ? 1 : 2);\n"`,
+		"msgstr[0] \"none\"\nmsgstr[1] \"%n files\"\nmsgstr[2] \"%n files\"\n",
+		"msgstr[0] \"\"\nmsgstr[1] \"one\"\nmsgstr[2] \"%n items\"\n",
+	} {
+		if !strings.Contains(po, expected) {
+			t.Errorf("PO should contain %q, but\n%s", expected, po)
+		}
+	}
+	result, err := readPO(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range entries {
+		if !reflect.DeepEqual(result[i].texts, entries[i].texts) {
+			t.Errorf("Pluralisation should be read from forms, but %q", result[i].texts)
+		}
+	}
+}
+
+// gettextPO is in the form that gettext tools (msgmerge and msgcat) write:
+// translator comments, previous msgids, wrapped lines, c-format flags and C escape sequences.
+const gettextPO = `# Japanese translations for app.
+# This file is distributed under the same license as the app package.
+#
+msgid ""
+msgstr ""
+"Project-Id-Version: app 1.0\n"
+"Language: ja\n"
+"MIME-Version: 1.0\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Content-Transfer-Encoding: 8bit\n"
+"Plural-Forms: nplurals=3; plural=(n<=0 ? 0 : n<=1 ? 1 : 2);\n"
+
+# reviewed by translators
+#. button label
+#: app/page.go:12
+msgid "Save"
+msgstr "保存"
+
+#, c-format
+#| msgid "Dont save"
+msgid "Don\'t save"
+msgstr "\'保存しない\' \x41\101\?"
+
+#, range:..0, range:1..1, range:2..
+msgid "%n files"
+msgid_plural "%n files"
+msgstr[0] "ファイルがありません"
+msgstr[1] "1 個のファイル"
+msgstr[2] ""
+"%n 個の"
+"ファイル"
+
+#, fuzzy, range:1..1, range:2..
+msgctxt "billing"
+msgid "%n items"
+msgid_plural "%n items"
+msgstr[0] ""
+msgstr[1] "1 点"
+msgstr[2] "%n 点"
+
+#~ msgid "Old"
+#~ msgstr "古い"
+`
+
+func TestReadGettextPO(t *testing.T) {
+	entries, err := readPO(strings.NewReader(gettextPO))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("It should read 4 entries, but %d", len(entries))
+	}
+	if entries[0].key != "Save" || entries[0].comment != "button label" || !reflect.DeepEqual(entries[0].refs, []string{"app/page.go:12"}) {
+		t.Errorf("It should read comments and references, but %+v", entries[0])
+	}
+	if entries[1].key != "Don't save" || entries[1].texts[0] != "'保存しない' AA?" {
+		t.Errorf("It should decode C escape sequences, but %q: %q", entries[1].key, entries[1].texts)
+	}
+	if expected := []string{"ファイルがありません", "1 個のファイル", "%n 個のファイル"}; !reflect.DeepEqual(entries[2].texts, expected) {
+		t.Errorf("It should read wrapped plural forms, but %q", entries[2].texts)
+	}
+	items := entries[3]
+	if expected := []string{"1 点", "%n 点"}; !reflect.DeepEqual(items.texts, expected) || !items.fuzzy || items.namespace != "billing" {
+		t.Errorf("It should read plural forms that ranges belong to, but %+v", items)
+	}
+	if _, err := readPO(strings.NewReader(`msgid "a\"` + "\n")); err == nil {
+		t.Error("It should return error for broken string")
+	}
+}
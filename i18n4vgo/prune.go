@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/shibukawa/i18n4v"
+	"io"
+	"sort"
+	"strings"
+)
+
+// usedKeys keeps keys that are found in sources.
+type usedKeys struct {
+	all        map[string]bool
+	namespaces map[string]map[string]bool
+}
+
+func newUsedKeys() *usedKeys {
+	return &usedKeys{
+		all:        make(map[string]bool),
+		namespaces: make(map[string]map[string]bool),
+	}
+}
+
+func (u *usedKeys) add(namespace, key string) {
+	u.all[key] = true
+	keys, ok := u.namespaces[namespace]
+	if !ok {
+		keys = make(map[string]bool)
+		u.namespaces[namespace] = keys
+	}
+	keys[key] = true
+}
+
+// isUsed returns true if the key in the namespace is used.
+// Keys in common namespace are used from all namespaces because scoped translators fall back to it.
+// Keys whose namespace is not known are recorded in common namespace (like calls via translators in
+// struct fields or parameters, and keys from JS extractor), so they are used in all namespaces too.
+func (u *usedKeys) isUsed(namespace, key string) bool {
+	if namespace == "" {
+		return u.all[key]
+	}
+	return u.namespaces[namespace][key] || u.namespaces[""][key]
+}
+
+// prune removes keys that are not used from dictionaries of all locales at once.
+//
+// Keys are collected from usages in sources and dictionaries that JS extractor outputs.
+// If obsolete is true, unused entries are moved to obsolete section instead of removing,
+// and obsolete entries that are used again are restored.
+// If dryRun is true, it writes differences in unified diff format to w and doesn't modify files.
+func prune(usages []*usage, jsDictionaries, locales []string, obsolete, dryRun bool, w io.Writer) error {
+	used := newUsedKeys()
+	for _, found := range usages {
+		used.add(found.namespace, found.key)
+	}
+	for _, file := range jsDictionaries {
+		dict, err := loadDictionary(file)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		for _, entry := range dict.entries() {
+			used.add(entry.namespace, entry.key)
+		}
+	}
+	// safety net for wrong inputs: removing all keys is not what anyone wants
+	if len(used.all) == 0 {
+		return fmt.Errorf("%s", tr("No translation keys are found in sources. Nothing is pruned."))
+	}
+	for _, file := range locales {
+		dict, err := loadDictionary(file)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		before, err := dict.marshal()
+		if err != nil {
+			return err
+		}
+		count := dict.prune(used, obsolete)
+		after, err := dict.marshal()
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(before, after) {
+			continue
+		}
+		if dryRun {
+			if err := writeUnifiedDiff(w, file, splitLines(before), splitLines(after)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := dict.save(file); err != nil {
+			return err
+		}
+		message := "%{file}: %{count} unused keys are removed."
+		if obsolete {
+			message = "%{file}: %{count} unused keys are moved to obsolete section."
+		}
+		fmt.Fprintln(w, tr(message, i18n4v.Replace{"file": file, "count": count}))
+	}
+	return nil
+}
+
+func splitLines(data []byte) []string {
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// prune removes unused keys from all namespaces and returns the number of them.
+func (d *dictionary) prune(used *usedKeys, obsolete bool) int {
+	count := 0
+	for _, name := range append([]string{""}, d.namespaceNames()...) {
+		dict := d.namespace(name, false)
+		isUsed := func(key string) bool {
+			return used.isUsed(name, key)
+		}
+		if dict.Obsolete != nil {
+			dict.Obsolete.moveEntries(dict, isUsed)
+		}
+		var target *dictionary
+		if obsolete {
+			if dict.Obsolete == nil {
+				dict.Obsolete = newDictionary()
+			}
+			target = dict.Obsolete
+		}
+		count += dict.moveEntries(target, func(key string) bool {
+			return !isUsed(key)
+		})
+		if dict.Obsolete != nil && dict.Obsolete.isEmpty() {
+			dict.Obsolete = nil
+		}
+		if name != "" && dict.isEmpty() && dict.Obsolete == nil {
+			delete(d.Namespaces, name)
+		}
+	}
+	return count
+}
+
+// isEmpty returns true if the dictionary has no entries in root values and contexts.
+func (d *dictionary) isEmpty() bool {
+	return len(d.Values) == 0 && len(d.Contexts) == 0
+}
+
+// moveEntries moves entries whose keys match to target with their html, fuzzy and meta information.
+// If target is nil, they are removed. It returns the number of moved keys.
+func (d *dictionary) moveEntries(target *dictionary, match func(key string) bool) int {
+	moved := make(map[string]bool)
+	move := func(values map[string]interface{}, matches map[string]string, fuzzy *[]string) {
+		for key, value := range values {
+			if !match(key) {
+				continue
+			}
+			delete(values, key)
+			moved[key] = true
+			isFuzzy := containsString(*fuzzy, key)
+			*fuzzy = removeString(*fuzzy, key)
+			if target == nil {
+				continue
+			}
+			targetValues := target.Values
+			if context := target.context(matches, true); context != nil {
+				targetValues = context.Values
+			}
+			// keep translated entry if the target already has it
+			if existing, ok := targetValues[key]; !ok || !hasText(existing) {
+				targetValues[key] = value
+				if isFuzzy {
+					target.markFuzzy("", key, matches, nil)
+				}
+			}
+		}
+	}
+	move(d.Values, nil, &d.Fuzzy)
+	var contexts []*dictionaryContext
+	for _, context := range d.Contexts {
+		move(context.Values, context.Matches, &context.Fuzzy)
+		if len(context.Values) > 0 {
+			contexts = append(contexts, context)
+		}
+	}
+	d.Contexts = contexts
+	keys := make([]string, 0, len(moved))
+	for key := range moved {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if target != nil {
+			if containsString(d.HTML, key) && !containsString(target.HTML, key) {
+				target.HTML = append(target.HTML, key)
+				sort.Strings(target.HTML)
+			}
+			if meta, ok := d.Meta[key]; ok {
+				if _, exists := target.Meta[key]; !exists {
+					*target.meta("", key) = *meta
+				}
+			}
+		}
+		d.HTML = removeString(d.HTML, key)
+		delete(d.Meta, key)
+	}
+	return len(keys)
+}
+
+// hasText returns true if the translation value has any text.
+func hasText(value interface{}) bool {
+	for _, text := range translationTexts(value) {
+		if text != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(values []string, value string) []string {
+	var result []string
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
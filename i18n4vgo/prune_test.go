@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files in a temporary directory and returns it. Caller should remove it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "i18n4vgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestPruneNamespaces(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app/app.go": `package app
+
+import "github.com/shibukawa/i18n4v"
+
+type S struct {
+	tr *i18n4v.Translator
+}
+
+func New(t *i18n4v.Translator) *S {
+	return &S{tr: t.Namespace("billing")}
+}
+
+func (s *S) Pay() string {
+	admin := i18n4v.SelectTranslator("ja").Namespace("admin")
+	admin.Translate("Users")
+	return s.tr.Translate("Pay")
+}
+`,
+		"ja.json": `{
+    "values": {"Pay": "支払う", "Unused": "未使用"},
+    "namespaces": {
+        "billing": {"values": {"Pay": "お支払い", "Refund": "返金"}},
+        "admin": {"values": {"Users": "ユーザー", "Pay": "支払い"}}
+    }
+}`,
+	})
+	defer os.RemoveAll(dir)
+	usages, err := extractGo([]string{filepath.Join(dir, "app/app.go")}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locale := filepath.Join(dir, "ja.json")
+	var buffer bytes.Buffer
+	if err := prune(usages, nil, []string{locale}, false, false, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	dict, err := loadDictionary(locale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := dict.Values["Unused"]; ok {
+		t.Error("Unused key should be removed")
+	}
+	billing := dict.namespace("billing", false)
+	if billing == nil || billing.Values["Pay"] != "お支払い" {
+		t.Errorf("Key used via translator in struct field should be kept in all namespaces, but %v", billing)
+	}
+	if _, ok := billing.Values["Refund"]; ok {
+		t.Error("Unused key in namespace should be removed")
+	}
+	admin := dict.namespace("admin", false)
+	if admin == nil || admin.Values["Users"] != "ユーザー" || admin.Values["Pay"] != "支払い" {
+		t.Errorf("Keys used in admin namespace should be kept, but %v", admin)
+	}
+}
+
+func TestPruneObsolete(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"ja.json": `{
+    "values": {"Save": "保存", "Old": "古い"},
+    "html": ["Old"],
+    "meta": {"Old": {"comment": "old button"}},
+    "obsolete": {"values": {"Open": "開く"}}
+}`,
+	})
+	defer os.RemoveAll(dir)
+	locale := filepath.Join(dir, "ja.json")
+	usages := []*usage{{key: "Save"}, {key: "Open"}}
+	var buffer bytes.Buffer
+	if err := prune(usages, nil, []string{locale}, true, false, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	dict, err := loadDictionary(locale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dict.Values["Open"] != "開く" {
+		t.Errorf("Obsolete key that is used again should be restored, but %v", dict.Values)
+	}
+	if _, ok := dict.Values["Old"]; ok || dict.Obsolete == nil || dict.Obsolete.Values["Old"] != "古い" {
+		t.Fatalf("Unused key should be moved to obsolete section, but %v", dict.Obsolete)
+	}
+	if !containsString(dict.Obsolete.HTML, "Old") || dict.Obsolete.Meta["Old"].Comment != "old button" {
+		t.Error("html and meta should be moved with the key")
+	}
+	if containsString(dict.HTML, "Old") || dict.Meta["Old"] != nil {
+		t.Error("html and meta of moved key should be removed")
+	}
+}
+
+func TestPruneDryRun(t *testing.T) {
+	src := `{
+    "values": {
+        "Save": "保存",
+        "Unused": "未使用"
+    }
+}
+`
+	dir := writeFiles(t, map[string]string{"ja.json": src})
+	defer os.RemoveAll(dir)
+	locale := filepath.Join(dir, "ja.json")
+	var buffer bytes.Buffer
+	if err := prune([]*usage{{key: "Save"}}, nil, []string{locale}, false, true, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	if readFile(t, locale) != src {
+		t.Error("Dry run should not modify files")
+	}
+	diff := buffer.String()
+	if !strings.Contains(diff, "--- "+locale) || !strings.Contains(diff, `-        "Save": "保存",`) ||
+		!strings.Contains(diff, `+        "Save": "保存"`) || !strings.Contains(diff, `-        "Unused": "未使用"`) {
+		t.Errorf("It should write unified diff, but\n%s", diff)
+	}
+	if err := prune(nil, nil, []string{locale}, false, true, &buffer); err == nil {
+		t.Error("It should refuse to prune when no keys are found")
+	}
+}
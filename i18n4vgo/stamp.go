@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"github.com/shibukawa/i18n4v"
+	"io"
+)
+
+// stamp records source hashes of reference dictionary in meta of translated keys in targets.
+// compare command reports keys whose source texts are changed after that.
+//
+// Keys that already have source hashes are kept unless all is true (use it after reviewing stale translations).
+// Untranslated keys, fuzzy keys and keys that don't exist in reference are skipped.
+func stamp(reference string, targets []string, all bool, w io.Writer) error {
+	ref, err := loadDictionary(reference)
+	if err != nil {
+		return fmt.Errorf("%s: %v", reference, err)
+	}
+	for _, file := range targets {
+		dict, err := loadDictionary(file)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		seen := make(map[string]bool)
+		translated := make(map[string]bool)
+		var keys []*catalogEntry
+		for _, entry := range dict.entries() {
+			id := entry.namespace + "\x00" + entry.key
+			if !seen[id] {
+				seen[id] = true
+				keys = append(keys, entry)
+			}
+			if entry.fuzzy {
+				continue
+			}
+			for _, text := range entry.texts {
+				if text != "" {
+					translated[id] = true
+				}
+			}
+		}
+		count := 0
+		for _, entry := range keys {
+			if !translated[entry.namespace+"\x00"+entry.key] {
+				continue
+			}
+			texts := ref.sourceTexts(entry.namespace, entry.key)
+			if len(texts) == 0 {
+				continue
+			}
+			hash := i18n4v.SourceHash(texts)
+			if current, ok := dict.namespace(entry.namespace, false).Meta[entry.key]; ok && current.Source != "" && (!all || current.Source == hash) {
+				continue
+			}
+			dict.meta(entry.namespace, entry.key).Source = hash
+			count++
+		}
+		if count == 0 {
+			continue
+		}
+		if err := dict.save(file); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, tr("%{file}: source hashes of %{count} keys are recorded.", i18n4v.Replace{"file": file, "count": count}))
+	}
+	return nil
+}
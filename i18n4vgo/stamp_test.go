@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"github.com/shibukawa/i18n4v"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const stampReferenceJSON = `{
+    "values": {
+        "Save": "Save",
+        "_empty": "",
+        "%n files": [[null, 0, "No files"], [1, 1, "%n file"], [2, null, "%n files"]],
+        "%{name} replied": {
+            "select": "gender",
+            "cases": {
+                "other": "%{name} replied",
+                "female": {
+                    "select": "case",
+                    "cases": {"other": "%{name} replied", "dative": "to %{name}"}
+                },
+                "male": ""
+            },
+            "fuzzy": ["male"]
+        }
+    },
+    "contexts": [
+        {"matches": {"plan": "pro"}, "values": {"Save": "Save all"}},
+        {"matches": {"gender": "female"}, "values": {"Save": "Save hers", "%n files": [[null, null, "%n of her files"]]}}
+    ],
+    "namespaces": {
+        "billing": {
+            "values": {"Save": "Save invoice"},
+            "contexts": [{"matches": {"plan": "pro"}, "values": {"Save": ""}}]
+        }
+    }
+}`
+
+const stampTargetJSON = `{
+    "values": {
+        "Save": "保存",
+        "_empty": "空",
+        "%n files": [[null, null, "%n ファイル"]],
+        "%{name} replied": {"select": "gender", "cases": {"other": "%{name}さんが返信"}}
+    },
+    "namespaces": {
+        "billing": {"values": {"Save": "請求書を保存"}}
+    }
+}`
+
+// compareFiles runs i18n4v.Compare that compare command uses.
+func compareFiles(t *testing.T, reference, target string) []*i18n4v.Problem {
+	ref, err := os.Open(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	tgt, err := os.Open(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tgt.Close()
+	problems, err := i18n4v.Compare(ref, tgt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return problems
+}
+
+func staleKeys(problems []*i18n4v.Problem) []string {
+	var result []string
+	for _, problem := range problems {
+		if problem.Kind == i18n4v.StaleTranslation {
+			result = append(result, problem.Context+" "+problem.Key)
+		}
+	}
+	return result
+}
+
+func TestStampMatchesCompare(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"en.json": stampReferenceJSON, "ja.json": stampTargetJSON})
+	defer os.RemoveAll(dir)
+	reference := filepath.Join(dir, "en.json")
+	target := filepath.Join(dir, "ja.json")
+	var buffer bytes.Buffer
+	if err := stamp(reference, []string{target}, false, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	dict, err := loadDictionary(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dict.Meta) != 4 || dict.namespace("billing", false).Meta["Save"] == nil {
+		t.Fatalf("Source hashes should be recorded, but %v", readFile(t, target))
+	}
+	if stale := staleKeys(compareFiles(t, reference, target)); len(stale) != 0 {
+		t.Errorf("Hashes recorded by stamp should match Compare, but %v", stale)
+	}
+	// changing source texts makes translations stale
+	changed := strings.Replace(stampReferenceJSON, `"to %{name}"`, `"for %{name}"`, 1)
+	changed = strings.Replace(changed, `"Save invoice"`, `"Save the invoice"`, 1)
+	if err := ioutil.WriteFile(reference, []byte(changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stale := staleKeys(compareFiles(t, reference, target))
+	if len(stale) != 2 || !strings.HasSuffix(stale[0], "%{name} replied") || !strings.HasSuffix(stale[1], "Save") {
+		t.Errorf("Changed source texts should be reported as stale, but %v", stale)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// templateFunctions keeps names of translation functions in templates.
+type templateFunctions struct {
+	// funcs accept the same parameters as Translate like {{t "Save"}}.
+	funcs map[string]bool
+	// pluralFuncs accept count as the second parameter like {{tn "%n files" .Count}}.
+	pluralFuncs map[string]bool
+}
+
+func newTemplateFunctions(funcs, pluralFuncs []string) *templateFunctions {
+	result := &templateFunctions{
+		funcs:       make(map[string]bool),
+		pluralFuncs: make(map[string]bool),
+	}
+	for _, name := range funcs {
+		result.funcs[name] = true
+	}
+	for _, name := range pluralFuncs {
+		result.pluralFuncs[name] = true
+	}
+	return result
+}
+
+// extractTemplates parses text/template and html/template files and returns translation keys in them.
+func extractTemplates(files []string, functions *templateFunctions) ([]*usage, error) {
+	var result []*usage
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		usages, err := extractTemplate(file, string(src), functions)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, usages...)
+	}
+	return result, nil
+}
+
+func extractTemplate(file, src string, functions *templateFunctions) ([]*usage, error) {
+	// functions are registered at runtime. Skip checking them.
+	tree := parse.New(file)
+	tree.Mode = parse.SkipFuncCheck
+	trees := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(src, "", "", trees); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(trees))
+	for name := range trees {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result []*usage
+	for _, name := range names {
+		tree := trees[name]
+		if tree.Root == nil {
+			continue
+		}
+		walkTemplate(tree.Root, func(command *parse.CommandNode, args []parse.Node) {
+			if found := templateUsage(tree, command, args, functions); found != nil {
+				found.file = file
+				found.pkg = filepath.Dir(file)
+				result = append(result, found)
+			}
+		})
+	}
+	return result, nil
+}
+
+// walkTemplate calls fn for all commands in the node. args are arguments of the command including
+// the value that is passed from the previous command in pipeline like {{"Save" | t}}.
+func walkTemplate(node parse.Node, fn func(command *parse.CommandNode, args []parse.Node)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTemplate(child, fn)
+		}
+	case *parse.ActionNode:
+		walkTemplate(n.Pipe, fn)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for i, command := range n.Cmds {
+			args := command.Args
+			if i > 0 {
+				args = append(append([]parse.Node{}, command.Args...), pipedValue(n.Cmds[i-1]))
+			}
+			fn(command, args)
+			for _, arg := range command.Args {
+				walkTemplate(arg, fn)
+			}
+		}
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		walkTemplate(n.Pipe, fn)
+	}
+}
+
+// pipedValue returns the node that has the result of the command in pipeline.
+// Commands that have only one value like "Save" or .Count return the value itself.
+func pipedValue(command *parse.CommandNode) parse.Node {
+	if len(command.Args) == 1 {
+		return command.Args[0]
+	}
+	return command
+}
+
+func walkBranch(node *parse.BranchNode, fn func(command *parse.CommandNode, args []parse.Node)) {
+	walkTemplate(node.Pipe, fn)
+	walkTemplate(node.List, fn)
+	walkTemplate(node.ElseList, fn)
+}
+
+// templateUsage returns usage if the command calls translation function with string literal.
+// It follows the parameter order of Translate: key, default text, count, replace, context.
+// Types of values in templates are not known. A value at the count position is treated as count
+// if it is a number or the key (or default text) has %n. Other values are passed as default text at runtime.
+func templateUsage(tree *parse.Tree, command *parse.CommandNode, args []parse.Node, functions *templateFunctions) *usage {
+	if len(args) < 2 {
+		return nil
+	}
+	ident, ok := args[0].(*parse.IdentifierNode)
+	if !ok || !(functions.funcs[ident.Ident] || functions.pluralFuncs[ident.Ident]) {
+		return nil
+	}
+	key, ok := args[1].(*parse.StringNode)
+	if !ok {
+		return nil
+	}
+	line, _ := tree.ErrorContext(command)
+	found := &usage{
+		key:    key.Text,
+		plural: functions.pluralFuncs[ident.Ident],
+		line:   templateLine(line),
+	}
+	args = args[2:]
+	if len(args) > 0 {
+		if text, ok := args[0].(*parse.StringNode); ok {
+			found.defaultText = text.Text
+			args = args[1:]
+		}
+	}
+	for i, arg := range args {
+		if helper := helperCommand(arg); helper != nil {
+			if name, ok := helper.Args[0].(*parse.IdentifierNode); ok {
+				switch name.Ident {
+				case "context":
+					found.context = templatePairs(helper.Args[1:])
+					continue
+				case "replace":
+					continue
+				}
+			}
+		}
+		if i != 0 {
+			continue
+		}
+		if _, ok := arg.(*parse.NumberNode); ok {
+			found.plural = true
+		} else if _, ok := arg.(*parse.StringNode); !ok && !found.plural {
+			found.plural = strings.Contains(found.key, "%n") || strings.Contains(found.defaultText, "%n")
+		}
+	}
+	return found
+}
+
+// helperCommand returns the command in the argument like (context "gender" "female").
+// Commands that are piped to the translation function are returned too.
+func helperCommand(arg parse.Node) *parse.CommandNode {
+	var command *parse.CommandNode
+	switch n := arg.(type) {
+	case *parse.PipeNode:
+		if len(n.Cmds) == 1 {
+			command = n.Cmds[0]
+		}
+	case *parse.CommandNode:
+		command = n
+	}
+	if command == nil || len(command.Args) == 0 {
+		return nil
+	}
+	return command
+}
+
+// templatePairs reads string literal pairs like (context "gender" "female").
+func templatePairs(args []parse.Node) map[string]string {
+	result := make(map[string]string)
+	for i := 0; i+1 < len(args); i += 2 {
+		key, ok := args[i].(*parse.StringNode)
+		if !ok {
+			continue
+		}
+		value, ok := args[i+1].(*parse.StringNode)
+		if !ok {
+			continue
+		}
+		result[key.Text] = value.Text
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// templateLine reads line number from error context like "file.tmpl:12:5".
+func templateLine(location string) int {
+	parts := strings.Split(location, ":")
+	if len(parts) < 3 {
+		return 0
+	}
+	line, _ := strconv.Atoi(parts[len(parts)-2])
+	return line
+}
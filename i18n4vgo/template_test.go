@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExtractTemplate(t *testing.T) {
+	src := `{{define "page"}}
+<h1>{{t "Hello" .Name}}</h1>
+<p>{{t "%n files" .Count}}</p>
+<p>{{tn "%n items" .Count}}</p>
+<p>{{t "_greeting" "Hi %{name}" (replace "name" .Name)}}</p>
+<p>{{t "%n days" 3}}</p>
+{{if .User}}<button>{{"Save" | t}}</button>{{end}}
+{{range .Items}}{{.Count | t "%n comments"}}{{end}}
+<p>{{t "Welcome" (replace "name" .Name) (context "gender" "female")}}</p>
+<p>{{context "gender" "male" | t "Bye" (replace "name" .Name)}}</p>
+{{end}}`
+	usages, err := extractTemplate("page.tmpl", src, newTemplateFunctions([]string{"t"}, []string{"tn"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key         string
+		defaultText string
+		plural      bool
+		line        int
+	}{
+		{"Hello", "", false, 2},
+		{"%n files", "", true, 3},
+		{"%n items", "", true, 4},
+		{"_greeting", "Hi %{name}", false, 5},
+		{"%n days", "", true, 6},
+		{"Save", "", false, 7},
+		{"%n comments", "", true, 8},
+		{"Welcome", "", false, 9},
+		{"Bye", "", false, 10},
+	}
+	if len(usages) != len(tests) {
+		t.Fatalf("It should extract %d keys, but %d", len(tests), len(usages))
+	}
+	for _, test := range tests {
+		found := findUsage(usages, test.key)
+		if found == nil {
+			t.Errorf("It should extract '%s'", test.key)
+			continue
+		}
+		if found.defaultText != test.defaultText || found.plural != test.plural || found.line != test.line {
+			t.Errorf("'%s' should have default text '%s', plural %v at line %d, but '%s', %v at line %d",
+				test.key, test.defaultText, test.plural, test.line, found.defaultText, found.plural, found.line)
+		}
+		if found.file != "page.tmpl" {
+			t.Errorf("'%s' should have file name, but '%s'", test.key, found.file)
+		}
+	}
+	if welcome := findUsage(usages, "Welcome"); !reflect.DeepEqual(welcome.context, map[string]string{"gender": "female"}) {
+		t.Errorf("It should read context, but %v", welcome.context)
+	}
+	if bye := findUsage(usages, "Bye"); !reflect.DeepEqual(bye.context, map[string]string{"gender": "male"}) {
+		t.Errorf("It should read piped context, but %v", bye.context)
+	}
+}
+
+func TestFindUsagesExclude(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app/app.go": `package app
+
+import "github.com/shibukawa/i18n4v"
+
+type Form struct {
+	Name string ` + "`msg:\"Name is required\"`" + `
+}
+
+func Title() string {
+	return i18n4v.Translate("Title")
+}
+`,
+		"app/page.tmpl":           `{{t "Page"}}`,
+		"app/generated/gen.go":    "package generated\n\nimport \"github.com/shibukawa/i18n4v\"\n\nvar _ = i18n4v.Translate(\"Generated\")\n",
+		"app/generated/page.tmpl": `{{t "Generated page"}}`,
+	})
+	defer os.RemoveAll(dir)
+	exclude := "generated"
+	goExclude := ""
+	options := &sourceOptions{
+		exclude:             &exclude,
+		goExclude:           &goExclude,
+		funcs:               &[]string{},
+		tags:                &[]string{"msg"},
+		templateExts:        &[]string{".tmpl"},
+		templateFuncs:       &[]string{"t"},
+		templatePluralFuncs: &[]string{"tn"},
+		usageLogs:           &[]string{},
+	}
+	usages, err := findUsages([]string{filepath.Join(dir, "app")}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, found := range usages {
+		keys = append(keys, found.key)
+	}
+	if !reflect.DeepEqual(keys, []string{"Title", "Name is required", "Page"}) {
+		t.Errorf("It should extract keys in Go files, struct tags and templates except excluded files, but %v", keys)
+	}
+	tag := findUsage(usages, "Name is required")
+	if tag.line != 6 || tag.pkg != filepath.Join(dir, "app") {
+		t.Errorf("Struct tag should have source reference, but %+v", tag)
+	}
+	exclude, goExclude = "", "generated/page"
+	usages, err = findUsages([]string{filepath.Join(dir, "app")}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 4 || findUsage(usages, "Generated page") != nil {
+		t.Errorf("Deprecated --go-exclude should work as --exclude, but %d keys", len(usages))
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// usageRecord is a line of key usage log that i18n4v.UsageLog writes.
+type usageRecord struct {
+	Locale    string `json:"locale"`
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Found     bool   `json:"found"`
+}
+
+// runtimePackage is a package name of keys in usage logs. Logs don't record packages that use the keys.
+const runtimePackage = "(runtime)"
+
+// readUsageLogs reads key usage logs that are recorded at runtime.
+// Keys in them are treated as used like keys found in source files.
+func readUsageLogs(files []string) ([]*usage, error) {
+	var result []*usage
+	found := make(map[string]bool)
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			var record usageRecord
+			if err := json.Unmarshal([]byte(text), &record); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("%s:%d: %v", file, line, err)
+			}
+			// same keys in other locales are duplicated
+			id := record.Namespace + "\x00" + record.Key
+			if record.Key == "" || found[id] {
+				continue
+			}
+			found[id] = true
+			result = append(result, &usage{
+				key:       record.Key,
+				namespace: record.Namespace,
+				file:      file,
+				line:      line,
+				pkg:       runtimePackage,
+			})
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type xliffDocument struct {
+	XMLName xml.Name     `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+	Version string       `xml:"version,attr"`
+	Files   []*xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	Original       string       `xml:"original,attr"`
+	SourceLanguage string       `xml:"source-language,attr"`
+	TargetLanguage string       `xml:"target-language,attr,omitempty"`
+	Datatype       string       `xml:"datatype,attr"`
+	Units          []*xliffUnit `xml:"body>trans-unit"`
+}
+
+// xliffUnit is a trans-unit. Each pluralisation range is written as its own trans-unit that has the same resname.
+// Attributes in "https://github.com/shibukawa/i18n4v" namespace keep information of the JSON dictionary.
+type xliffUnit struct {
+	ID      string `xml:"id,attr"`
+	Resname string `xml:"resname,attr"`
+	// Context keeps namespace and context like "billing{plan=free}".
+	Context string `xml:"https://github.com/shibukawa/i18n4v context,attr,omitempty"`
+	// Range keeps pluralisation range like "2..".
+	Range         string               `xml:"https://github.com/shibukawa/i18n4v range,attr,omitempty"`
+	HTML          string               `xml:"https://github.com/shibukawa/i18n4v html,attr,omitempty"`
+	Source        string               `xml:"source"`
+	Target        xliffTarget          `xml:"target"`
+	Notes         []xliffNote          `xml:"note"`
+	ContextGroups []*xliffContextGroup `xml:"context-group"`
+}
+
+type xliffTarget struct {
+	State string `xml:"state,attr,omitempty"`
+	Text  string `xml:",chardata"`
+}
+
+type xliffNote struct {
+	From string `xml:"from,attr,omitempty"`
+	Text string `xml:",chardata"`
+}
+
+type xliffContextGroup struct {
+	Purpose  string          `xml:"purpose,attr"`
+	Contexts []*xliffContext `xml:"context"`
+}
+
+type xliffContext struct {
+	Type string `xml:"context-type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// xliffState returns state of target. Fuzzy entries need review.
+func xliffState(entry *catalogEntry, text string) string {
+	switch {
+	case entry.fuzzy:
+		return "needs-review-translation"
+	case text == "":
+		return "new"
+	}
+	return "translated"
+}
+
+// writeXLIFF writes entries in XLIFF 1.2 format.
+// Source references are written as context groups and comments are written as notes from developer.
+func writeXLIFF(w io.Writer, entries []*catalogEntry, original, sourceLanguage, locale string) error {
+	file := &xliffFile{
+		Original:       original,
+		SourceLanguage: sourceLanguage,
+		TargetLanguage: locale,
+		Datatype:       "plaintext",
+	}
+	for _, entry := range entries {
+		for i, text := range entry.texts {
+			unit := &xliffUnit{
+				ID:      strconv.Itoa(len(file.Units) + 1),
+				Resname: entry.key,
+				Context: catalogContext(entry),
+				Source:  entry.key,
+				Target:  xliffTarget{State: xliffState(entry, text), Text: text},
+			}
+			if entry.plural && i < len(entry.bounds) {
+				unit.Range = formatRange(entry.bounds[i])
+			}
+			if entry.html {
+				unit.HTML = "yes"
+			}
+			if entry.comment != "" {
+				unit.Notes = append(unit.Notes, xliffNote{From: "developer", Text: entry.comment})
+			}
+			for _, ref := range entry.refs {
+				group := &xliffContextGroup{Purpose: "location"}
+				sourceFile, line := ref, ""
+				if index := strings.LastIndex(ref, ":"); index != -1 {
+					sourceFile, line = ref[:index], ref[index+1:]
+				}
+				group.Contexts = append(group.Contexts, &xliffContext{Type: "sourcefile", Text: sourceFile})
+				if line != "" {
+					group.Contexts = append(group.Contexts, &xliffContext{Type: "linenumber", Text: line})
+				}
+				unit.ContextGroups = append(unit.ContextGroups, group)
+			}
+			file.Units = append(file.Units, unit)
+		}
+	}
+	document := &xliffDocument{
+		Version: "1.2",
+		Files:   []*xliffFile{file},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// readXLIFF reads entries from XLIFF 1.2 format that writeXLIFF writes.
+// trans-units that have the same resname and context are merged into one pluralisation entry.
+func readXLIFF(r io.Reader) ([]*catalogEntry, error) {
+	var document xliffDocument
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return nil, err
+	}
+	var result []*catalogEntry
+	found := make(map[string]*catalogEntry)
+	for _, file := range document.Files {
+		for _, unit := range file.Units {
+			key := unit.Resname
+			if key == "" {
+				key = unit.Source
+			}
+			id := unit.Context + "\x00" + key
+			entry, ok := found[id]
+			if !ok {
+				namespace, matches, cases := parseCatalogContext(unit.Context)
+				entry = &catalogEntry{
+					namespace: namespace,
+					matches:   matches,
+					key:       key,
+					cases:     cases,
+					html:      unit.HTML == "yes",
+				}
+				for _, note := range unit.Notes {
+					if note.From == "developer" || note.From == "" {
+						entry.comment = note.Text
+					}
+				}
+				for _, group := range unit.ContextGroups {
+					if group.Purpose != "location" {
+						continue
+					}
+					var ref, line string
+					for _, context := range group.Contexts {
+						switch context.Type {
+						case "sourcefile":
+							ref = context.Text
+						case "linenumber":
+							line = context.Text
+						}
+					}
+					if line != "" {
+						ref += ":" + line
+					}
+					entry.refs = append(entry.refs, ref)
+				}
+				found[id] = entry
+				result = append(result, entry)
+			}
+			if unit.Target.State == "needs-review-translation" {
+				entry.fuzzy = true
+			}
+			if unit.Range != "" {
+				bounds, err := parseRange(unit.Range)
+				if err != nil {
+					return nil, err
+				}
+				entry.plural = true
+				entry.bounds = append(entry.bounds, bounds)
+			}
+			entry.texts = append(entry.texts, unit.Target.Text)
+		}
+	}
+	return result, nil
+}
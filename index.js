@@ -84,19 +84,41 @@ Translator.prototype.removeCallback = function (key) {
     delete this.updateCallbacks[key];
 };
 
+// Keys in fuzzy list are draft translations and they are not used.
+var isFuzzy = function (fuzzy, key) {
+    if (fuzzy == null) {
+        return false;
+    }
+    for (var i = 0, len = fuzzy.length; i < len; i++) {
+        if (fuzzy[i] === key) {
+            return true;
+        }
+    }
+    return false;
+};
+
 Translator.prototype.add = function (d, lang) {
     var results;
     if (d.values != null) {
         var ref = d.values;
         for (var k in ref) {
-            this.data.values[k] = ref[k];
+            if (!isFuzzy(d.fuzzy, k)) {
+                this.data.values[k] = ref[k];
+            }
         }
     }
     if (d.contexts != null) {
         var ref1 = d.contexts;
         results = [];
         for (var i = 0, len = ref1.length; i < len; i++) {
-            results.push(this.data.contexts.push(ref1[i]));
+            var c = ref1[i];
+            var values = {};
+            for (var key in c.values) {
+                if (!isFuzzy(c.fuzzy, key)) {
+                    values[key] = c.values[key];
+                }
+            }
+            results.push(this.data.contexts.push({matches: c.matches, values: values}));
         }
     }
     for (var callbackKey in this.updateCallbacks) {
@@ -149,12 +171,12 @@ Translator.prototype.translateText = function (text, num, formatting, context, d
     if (this.data == null) {
         return this.useOriginalText(defaultText || text, num, formatting);
     }
-    var contextData = this.getContextData(this.data, context);
-    if (contextData != null) {
-        result = this.findTranslation(text, num, formatting, contextData.values);
+    var contexts = this.getMatchedContexts(this.data, context);
+    for (var i = 0, len = contexts.length; i < len && result == null; i++) {
+        result = this.findTranslation(text, num, formatting, contexts[i].values, context);
     }
     if (result == null) {
-        result = this.findTranslation(text, num, formatting, this.data.values);
+        result = this.findTranslation(text, num, formatting, this.data.values, context);
     }
     if (result == null) {
         return this.useOriginalText(defaultText || text, num, formatting);
@@ -162,8 +184,8 @@ Translator.prototype.translateText = function (text, num, formatting, context, d
     return result;
 };
 
-Translator.prototype.findTranslation = function (text, num, formatting, data) {
-    var value = data[text];
+Translator.prototype.findTranslation = function (text, num, formatting, data, context) {
+    var value = this.selectTranslation(data[text], formatting, context);
     if (value == null) {
         return null;
     }
@@ -185,26 +207,68 @@ Translator.prototype.findTranslation = function (text, num, formatting, data) {
     return null;
 };
 
+// Select forms choose a case by the value in context and then in formatting.
+// "other" case is used if no case matches.
+Translator.prototype.selectTranslation = function (value, formatting, context) {
+    var selectCase = function (select, caseName) {
+        var cases = select.cases || {};
+        var fuzzy = select.fuzzy || [];
+        if (caseName == null || !cases.hasOwnProperty(caseName) || fuzzy.indexOf(caseName) !== -1) {
+            return null;
+        }
+        return cases[caseName];
+    };
+    while (value != null && typeof value === "object" && typeof value.select === "string") {
+        var name = value.select;
+        var next = selectCase(value, context != null ? context[name] : null);
+        if (next == null && formatting != null && formatting[name] != null) {
+            next = selectCase(value, String(formatting[name]));
+        }
+        if (next == null) {
+            next = selectCase(value, "other");
+        }
+        value = next;
+    }
+    return value;
+};
+
 Translator.prototype.getContextData = function (data, context) {
+    var contexts = this.getMatchedContexts(data, context);
+    return contexts.length > 0 ? contexts[0] : null;
+};
+
+// returns matched contexts from the most specific one (that has the most conditions).
+// contexts that have the same number of conditions keep the order in the dictionary.
+Translator.prototype.getMatchedContexts = function (data, context) {
     if (data.contexts == null) {
-        return null;
+        return [];
     }
+    var matched = [];
     var ref = data.contexts;
     for (var i = 0, len = ref.length; i < len; i++) {
         var c = ref[i];
         var equal = true;
+        var count = 0;
         var ref1 = c.matches;
         for (var key in ref1) {
+            count++;
             if (ref1[key] !== context[key]) {
                 equal = false;
                 break;
             }
         }
         if (equal) {
-            return c;
+            matched.push({context: c, count: count, index: i});
         }
     }
-    return null;
+    matched.sort(function (a, b) {
+        return (b.count - a.count) || (a.index - b.index);
+    });
+    var result = [];
+    for (var j = 0; j < matched.length; j++) {
+        result.push(matched[j].context);
+    }
+    return result;
 };
 
 Translator.prototype.useOriginalText = function (text, num, formatting) {
@@ -0,0 +1,210 @@
+package i18n4v
+
+import (
+	"golang.org/x/text/language"
+	"sort"
+)
+
+// Pluralisation is a pluralisation entry of a translation.
+// Min and Max are math.MinInt64 and math.MaxInt64 if they are null in JSON.
+type Pluralisation struct {
+	Min         int64
+	Max         int64
+	Translation string
+}
+
+/*
+SelectForm is a select form of a translation. Translate chooses one of Cases by the value of Name
+in Context or Replace, and "other" case is used if no case matches.
+
+Values of Cases should be string, []Pluralisation or SelectForm.
+*/
+type SelectForm struct {
+	Name  string
+	Cases map[string]interface{}
+}
+
+/*
+Entry describes a translation entry in dictionary.
+
+Plain translation has Translation, and pluralisation has Pluralisations.
+Select form has the selector name in Select and the entry of each case in Cases.
+Context is matches of the context that has the entry. It is nil for root values.
+*/
+type Entry struct {
+	Key            string
+	Translation    string
+	Pluralisations []Pluralisation
+	Select         string
+	Cases          map[string]*Entry
+	Context        Context
+}
+
+// IsPlural returns true if the entry is pluralisation.
+func (e *Entry) IsPlural() bool {
+	return len(e.Pluralisations) != 0
+}
+
+// IsSelect returns true if the entry is select form.
+func (e *Entry) IsSelect() bool {
+	return e.Select != ""
+}
+
+func newEntry(key string, value *translation, matches Context) *Entry {
+	result := &Entry{
+		Key:         key,
+		Translation: value.translation,
+	}
+	for _, pluralisation := range value.pluralisations {
+		result.Pluralisations = append(result.Pluralisations, Pluralisation{
+			Min:         pluralisation.min,
+			Max:         pluralisation.max,
+			Translation: pluralisation.translation,
+		})
+	}
+	if value.selector != nil {
+		result.Select = value.selector.name
+		result.Cases = make(map[string]*Entry, len(value.selector.cases))
+		for caseName, caseValue := range value.selector.cases {
+			result.Cases[caseName] = newEntry(key, caseValue, matches)
+		}
+	}
+	if matches != nil {
+		result.Context = make(Context, len(matches))
+		for k, v := range matches {
+			result.Context[k] = v
+		}
+	}
+	return result
+}
+
+/*
+Keys method returns all keys in root values and contexts in sorted order.
+*/
+func (t *Translator) Keys() []string {
+	t = t.source()
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	found := make(map[string]bool)
+	for key := range t.values {
+		found[key] = true
+	}
+	for _, context := range t.contexts {
+		for key := range context.values {
+			found[key] = true
+		}
+	}
+	result := make([]string, 0, len(found))
+	for key := range found {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+/*
+Lookup method returns the entry of the key.
+
+If context is passed, it returns the entry that Translate uses for the context
+(it searches matched contexts and then root values). Otherwise it searches only root values.
+Translator scoped to a namespace searches the namespace and then the common namespace.
+*/
+func (t *Translator) Lookup(key string, context ...Context) (*Entry, bool) {
+	for _, source := range t.dictionaries() {
+		if entry, ok := source.lookup(key, context...); ok {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+func (t *Translator) lookup(key string, context ...Context) (*Entry, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if len(context) > 0 {
+		for _, found := range t.getContextData(context[0]) {
+			if value, ok := found.values[key]; ok {
+				return newEntry(key, value, found.matches), true
+			}
+		}
+	}
+	if value, ok := t.values[key]; ok {
+		return newEntry(key, value, nil), true
+	}
+	return nil, false
+}
+
+/*
+Has method returns true if the dictionary has translation of the key.
+If context is passed, contexts that match it are searched too.
+*/
+func (t *Translator) Has(key string, context ...Context) bool {
+	_, ok := t.Lookup(key, context...)
+	return ok
+}
+
+/*
+Contexts method returns matches of all contexts in registered order.
+*/
+func (t *Translator) Contexts() []Context {
+	t = t.source()
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	result := make([]Context, len(t.contexts))
+	for i, context := range t.contexts {
+		result[i] = make(Context, len(context.matches))
+		for k, v := range context.matches {
+			result[i][k] = v
+		}
+	}
+	return result
+}
+
+/*
+ContextKeys method returns keys in contexts that have the same matches in sorted order.
+*/
+func (t *Translator) ContextKeys(matches Context) []string {
+	t = t.source()
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	var result []string
+	for _, context := range t.contexts {
+		if !sameContext(context.matches, matches) {
+			continue
+		}
+		for key := range context.values {
+			result = append(result, key)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func sameContext(a, b Context) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if value, ok := b[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Tag method returns language of the translator.
+If it is not specified, it returns language.Und.
+*/
+func (t *Translator) Tag() language.Tag {
+	return t.tag
+}
+
+/*
+Languages returns registered language tags in registered order.
+*/
+func Languages() []language.Tag {
+	lock.Lock()
+	defer lock.Unlock()
+	return append([]language.Tag{}, languages...)
+}
@@ -0,0 +1,74 @@
+package i18n4v
+
+import (
+	"golang.org/x/text/language"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestIntrospection(t *testing.T) {
+	en := MustCreateFromString(`{
+        "values": {
+            "Hello": "Hello",
+            "%n comments": [
+                [0, 0, "No comments"],
+                [1, null, "%n comments"]
+            ],
+            "Welcome": {"select": "gender", "cases": {"female": "Welcome madam", "other": "Welcome"}}
+        },
+        "contexts": [
+            {
+                "matches": {"gender": "female"},
+                "values": {
+                    "Hello": "Hello madam",
+                    "Goodbye": "Goodbye madam"
+                }
+            }
+        ]
+    }`, language.English)
+	if keys := en.Keys(); !reflect.DeepEqual(keys, []string{"%n comments", "Goodbye", "Hello", "Welcome"}) {
+		t.Errorf("It should return all keys, but %v", keys)
+	}
+	entry, ok := en.Lookup("%n comments")
+	if !ok || !entry.IsPlural() {
+		t.Fatalf("It should return pluralisation entry, but %v", entry)
+	}
+	expected := []Pluralisation{{0, 0, "No comments"}, {1, math.MaxInt64, "%n comments"}}
+	if !reflect.DeepEqual(entry.Pluralisations, expected) {
+		t.Errorf("It should return pluralisations, but %v", entry.Pluralisations)
+	}
+	entry, ok = en.Lookup("Hello", Context{"gender": "female"})
+	if !ok || entry.Translation != "Hello madam" || entry.Context["gender"] != "female" {
+		t.Errorf("It should return entry in context, but %v", entry)
+	}
+	entry, ok = en.Lookup("Welcome")
+	if !ok || !entry.IsSelect() || entry.Select != "gender" || entry.Cases["female"].Translation != "Welcome madam" {
+		t.Errorf("It should return select form, but %v", entry)
+	}
+	if en.Has("Goodbye") {
+		t.Errorf("Goodbye is only in context")
+	}
+	if !en.Has("Goodbye", Context{"gender": "female"}) {
+		t.Errorf("Goodbye should be found in context")
+	}
+	if contexts := en.Contexts(); len(contexts) != 1 || contexts[0]["gender"] != "female" {
+		t.Errorf("It should return contexts, but %v", contexts)
+	}
+	if keys := en.ContextKeys(Context{"gender": "female"}); !reflect.DeepEqual(keys, []string{"Goodbye", "Hello"}) {
+		t.Errorf("It should return keys of context, but %v", keys)
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	Reset()
+	MustAddFromString(`{}`, language.Japanese)
+	MustAddFromString(`{}`, language.English)
+	defer Reset()
+	if tags := Languages(); !reflect.DeepEqual(tags, []language.Tag{language.Japanese, language.English}) {
+		t.Errorf("It should return registered languages, but %v", tags)
+	}
+	if tag := SelectTranslator("en").Tag(); tag != language.English {
+		t.Errorf("It should return tag of translator, but %v", tag)
+	}
+}
@@ -0,0 +1,154 @@
+package i18n4v
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// jsonNode is a JSON value with its position in source.
+// Unlike encoding/json's map, it keeps member order and duplicated keys.
+type jsonNode struct {
+	offset  int
+	value   interface{} // string, json.Number, bool, nil, []*jsonNode or []*jsonMember
+	isArray bool
+	isObj   bool
+}
+
+type jsonMember struct {
+	key       string
+	keyOffset int
+	value     *jsonNode
+}
+
+func (n *jsonNode) array() []*jsonNode {
+	if !n.isArray {
+		return nil
+	}
+	return n.value.([]*jsonNode)
+}
+
+func (n *jsonNode) members() []*jsonMember {
+	if !n.isObj {
+		return nil
+	}
+	return n.value.([]*jsonMember)
+}
+
+func (n *jsonNode) member(key string) *jsonMember {
+	for _, member := range n.members() {
+		if member.key == key {
+			return member
+		}
+	}
+	return nil
+}
+
+// jsonSource keeps raw JSON source to calculate line and column from offset.
+type jsonSource struct {
+	data []byte
+	dec  *json.Decoder
+}
+
+func parseJSONNode(reader io.Reader) (*jsonSource, *jsonNode, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	src := &jsonSource{
+		data: data,
+		dec:  json.NewDecoder(bytes.NewReader(data)),
+	}
+	src.dec.UseNumber()
+	node, err := src.parseNode()
+	if err != nil {
+		return src, nil, err
+	}
+	return src, node, nil
+}
+
+// tokenStart returns the beginning of the next token.
+func (s *jsonSource) tokenStart() int {
+	offset := int(s.dec.InputOffset())
+	for offset < len(s.data) {
+		switch s.data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func (s *jsonSource) position(offset int) (line, column int) {
+	line = 1
+	column = 1
+	for _, c := range s.data[:offset] {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return
+}
+
+func (s *jsonSource) parseNode() (*jsonNode, error) {
+	offset := s.tokenStart()
+	token, err := s.dec.Token()
+	if err != nil {
+		return nil, s.wrapError(err, offset)
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '[':
+			var items []*jsonNode
+			for s.dec.More() {
+				item, err := s.parseNode()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+			if _, err := s.dec.Token(); err != nil {
+				return nil, s.wrapError(err, s.tokenStart())
+			}
+			return &jsonNode{offset: offset, value: items, isArray: true}, nil
+		case '{':
+			var members []*jsonMember
+			for s.dec.More() {
+				keyOffset := s.tokenStart()
+				keyToken, err := s.dec.Token()
+				if err != nil {
+					return nil, s.wrapError(err, keyOffset)
+				}
+				value, err := s.parseNode()
+				if err != nil {
+					return nil, err
+				}
+				members = append(members, &jsonMember{
+					key:       keyToken.(string),
+					keyOffset: keyOffset,
+					value:     value,
+				})
+			}
+			if _, err := s.dec.Token(); err != nil {
+				return nil, s.wrapError(err, s.tokenStart())
+			}
+			return &jsonNode{offset: offset, value: members, isObj: true}, nil
+		}
+	}
+	return &jsonNode{offset: offset, value: token}, nil
+}
+
+func (s *jsonSource) wrapError(err error, offset int) error {
+	if syntaxError, ok := err.(*json.SyntaxError); ok {
+		offset = int(syntaxError.Offset)
+	}
+	line, column := s.position(offset)
+	return errors.Wrapf(err, "json parse error at %d:%d", line, column)
+}
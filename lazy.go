@@ -0,0 +1,166 @@
+package i18n4v
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"io"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Loader returns JSON dictionary of a locale. It is registered by AddLoader and called
+when the locale is used at the first time. If returned reader implements io.Closer, it is closed after loading.
+*/
+type Loader func() (io.Reader, error)
+
+/*
+FSLoader returns Loader that reads the file from fsys.
+It accepts any fs.FS like embed.FS and os.DirFS:
+
+	//go:embed locales
+	var locales embed.FS
+
+	i18n4v.AddLoader(language.Japanese, i18n4v.FSLoader(locales, "locales/ja.json"))
+*/
+func FSLoader(fsys fs.FS, name string) Loader {
+	return func() (io.Reader, error) {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(data), nil
+	}
+}
+
+type lazyLocale struct {
+	loader Loader
+	// mutex is locked during loading to run loader only once at the same time.
+	mutex sync.Mutex
+	// lastUsed keeps UnixNano of the time when the locale was selected.
+	lastUsed int64
+}
+
+func (l *lazyLocale) touch() {
+	atomic.StoreInt64(&l.lastUsed, time.Now().UnixNano())
+}
+
+var loaders = make(map[language.Tag]*lazyLocale)
+
+/*
+AddLoader registers the locale that is loaded lazily.
+
+The tag is used for language matching (like SelectTag) immediately, but loader runs only when
+the translator of the tag is used at the first time (like SelectTranslator).
+Even if many goroutines select the locale at the same time, loader runs only once.
+
+Locales registered by AddLoader can be unloaded by UnloadUnused and loaded again when they are used.
+*/
+func AddLoader(tag language.Tag, loader Loader) error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, ok := loaders[tag]; ok {
+		return errors.Errorf("Loader of '%s' is already registered", tag)
+	}
+	if _, ok := translators[tag]; ok {
+		return errors.Errorf("Dictionary of '%s' is already registered", tag)
+	}
+	loaders[tag] = &lazyLocale{loader: loader}
+	languages = append(languages, tag)
+	matcher = nil
+	return nil
+}
+
+/*
+Preload loads locales registered by AddLoader now. It is good for checking errors in dictionaries at startup.
+If no tag is passed, all locales are loaded.
+*/
+func Preload(tag ...language.Tag) error {
+	if len(tag) == 0 {
+		lock.Lock()
+		for key := range loaders {
+			tag = append(tag, key)
+		}
+		lock.Unlock()
+	}
+	for _, t := range tag {
+		if _, err := registeredTranslator(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registeredTranslator returns the registered translator of tag. If the locale is registered by AddLoader,
+// it is loaded at the first time. It returns nil if tag is not registered.
+func registeredTranslator(tag language.Tag) (*Translator, error) {
+	lock.Lock()
+	translator, ok := translators[tag]
+	lazy := loaders[tag]
+	lock.Unlock()
+	if lazy == nil || ok {
+		if lazy != nil {
+			lazy.touch()
+		}
+		return translator, nil
+	}
+
+	lazy.mutex.Lock()
+	defer lazy.mutex.Unlock()
+	lazy.touch()
+	// other goroutine may have loaded during waiting
+	lock.Lock()
+	translator, ok = translators[tag]
+	lock.Unlock()
+	if ok {
+		return translator, nil
+	}
+	reader, err := lazy.loader()
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't load dictionary of '%s'", tag)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	translator, err = Create(reader, tag)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't load dictionary of '%s'", tag)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if loaders[tag] != lazy {
+		// Reset was called during loading
+		return translator, nil
+	}
+	translators[tag] = translator
+	return translator, nil
+}
+
+/*
+UnloadUnused unloads locales registered by AddLoader that have not been selected for idle duration.
+It returns unloaded tags. They are loaded again when they are used.
+
+Words added by Add or AddWord to the unloaded locales are discarded.
+*/
+func UnloadUnused(idle time.Duration) []language.Tag {
+	lock.Lock()
+	defer lock.Unlock()
+
+	var result []language.Tag
+	threshold := time.Now().Add(-idle).UnixNano()
+	for _, tag := range languages {
+		lazy, ok := loaders[tag]
+		if !ok {
+			continue
+		}
+		if _, loaded := translators[tag]; loaded && atomic.LoadInt64(&lazy.lastUsed) <= threshold {
+			delete(translators, tag)
+			result = append(result, tag)
+		}
+	}
+	return result
+}
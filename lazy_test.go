@@ -0,0 +1,142 @@
+package i18n4v
+
+import (
+	"errors"
+	"golang.org/x/text/language"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLazyLoading(t *testing.T) {
+	Reset()
+	defer Reset()
+	var count int32
+	err := AddLoader(language.Japanese, func() (io.Reader, error) {
+		atomic.AddInt32(&count, 1)
+		time.Sleep(10 * time.Millisecond)
+		return strings.NewReader(`{"values": {"Hello": "こんにちは"}}`), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	MustAddFromString(`{"values": {"Hello": "Hello"}}`, language.English)
+	if tag := SelectTag("ja-JP"); tag != language.Japanese {
+		t.Errorf("Lazy locale should be used for matching, but %v", tag)
+	}
+	if count != 0 {
+		t.Error("Loader should not run before the locale is used")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result := Select("ja")("Hello"); result != "こんにちは" {
+				t.Errorf("It should use loaded dictionary, but '%s'", result)
+			}
+		}()
+	}
+	wg.Wait()
+	if count != 1 {
+		t.Errorf("Loader should run only once, but %d", count)
+	}
+
+	if unloaded := UnloadUnused(time.Hour); len(unloaded) != 0 {
+		t.Errorf("Recently used locale should not be unloaded, but %v", unloaded)
+	}
+	unloaded := UnloadUnused(0)
+	if len(unloaded) != 1 || unloaded[0] != language.Japanese {
+		t.Errorf("Lazy locale should be unloaded, but %v", unloaded)
+	}
+	if result := Select("ja")("Hello"); result != "こんにちは" {
+		t.Errorf("Unloaded locale should be loaded again, but '%s'", result)
+	}
+	if count != 2 {
+		t.Errorf("Loader should run again after unloading, but %d", count)
+	}
+	if err := AddLoader(language.English, FSLoader(fstest.MapFS{}, "en.json")); err == nil {
+		t.Error("It should return error for registered tag")
+	}
+}
+
+func TestFSLoader(t *testing.T) {
+	Reset()
+	defer Reset()
+	fsys := fstest.MapFS{
+		"locales/fr.json": {Data: []byte(`{"values": {"Hello": "Bonjour"}}`)},
+		"locales/de.json": {Data: []byte(`{"values": `)},
+	}
+	AddLoader(language.French, FSLoader(fsys, "locales/fr.json"))
+	AddLoader(language.German, FSLoader(fsys, "locales/de.json"))
+	AddLoader(language.Spanish, func() (io.Reader, error) {
+		return nil, errors.New("not found")
+	})
+	if err := Preload(language.French); err != nil {
+		t.Errorf("It should load dictionary: %v", err)
+	}
+	if err := Preload(); err == nil {
+		t.Error("It should return error for broken dictionaries")
+	}
+	if result := SelectTranslator("fr").Translate("Hello"); result != "Bonjour" {
+		t.Errorf("It should use dictionary in fs, but '%s'", result)
+	}
+	if translator := SelectTranslator("es"); translator != defaultTranslator {
+		t.Error("It should return default translator when loading fails")
+	}
+}
+
+func TestAddToNamespaceWhileUnloading(t *testing.T) {
+	Reset()
+	defer Reset()
+	AddLoader(language.Japanese, func() (io.Reader, error) {
+		return strings.NewReader(`{"values": {"Save": "保存"}}`), nil
+	})
+
+	done := make(chan struct{})
+	var unloader sync.WaitGroup
+	unloader.Add(1)
+	go func() {
+		defer unloader.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				UnloadUnused(0)
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if err := AddToNamespace("billing", strings.NewReader(`{"values": {"Pay": "支払う"}}`), language.Japanese); err != nil {
+					t.Error(err)
+					return
+				}
+				// the translator may be unloaded again. Check it only if it is still loaded.
+				lock.Lock()
+				translator := translators[language.Japanese]
+				lock.Unlock()
+				if translator != nil && translator.Translate("Save") != "保存" {
+					t.Error("Dictionary of loader should not be replaced by the namespace")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	unloader.Wait()
+	if r := SelectTranslator("ja").Translate("Save"); r != "保存" {
+		t.Errorf("Dictionary of loader should not be lost, but %s", r)
+	}
+}
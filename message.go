@@ -0,0 +1,176 @@
+package i18n4v
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	htmltemplate "html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"sync"
+	texttemplate "text/template"
+)
+
+// Message is a localised message like an email that is rendered by MessageTemplates.
+type Message struct {
+	Tag     language.Tag
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type messageTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+/*
+MessageTemplates loads text/template and html/template files for each locale and renders messages
+like emails.
+
+Templates are searched from file system by name and language tag:
+
+    welcome.ja-JP.tmpl       text part for ja-JP
+    welcome.ja.tmpl          text part for ja (fallback of ja-JP)
+    welcome.tmpl             text part for other languages
+    welcome.ja.html.tmpl     HTML part for ja (optional)
+    welcome.html.tmpl        HTML part for other languages (optional)
+
+Text part should define "subject" template for subject:
+
+    {{define "subject"}}{{t "Welcome to %{service}" (replace "service" .Service)}}{{end}}
+    {{t "Hello %{name}" (replace "name" .Name)}}
+
+Templates can use t, tn, replace and context functions (see FuncMap).
+They are bound to the translator of the message's language.
+*/
+type MessageTemplates struct {
+	fsys  fs.FS
+	lock  sync.RWMutex
+	cache map[string]*messageTemplate
+}
+
+/*
+CreateMessageTemplates returns new MessageTemplates instance that reads templates from fsys.
+It accepts any fs.FS like os.DirFS and embed.FS.
+*/
+func CreateMessageTemplates(fsys fs.FS) *MessageTemplates {
+	return &MessageTemplates{
+		fsys:  fsys,
+		cache: make(map[string]*messageTemplate),
+	}
+}
+
+// localeSuffixes returns file name suffixes for tag from specific to generic like ".ja-JP", ".ja", "".
+func localeSuffixes(tag language.Tag) []string {
+	var result []string
+	for t := tag; t != language.Und; t = t.Parent() {
+		result = append(result, "."+t.String())
+	}
+	return append(result, "")
+}
+
+func (m *MessageTemplates) load(name string, tag language.Tag) (*messageTemplate, error) {
+	cacheKey := name + "\x00" + tag.String()
+	m.lock.RLock()
+	result, ok := m.cache[cacheKey]
+	m.lock.RUnlock()
+	if ok {
+		return result, nil
+	}
+	// templates are read without the lock not to block rendering of cached templates by slow file systems
+	result = &messageTemplate{}
+	for _, suffix := range localeSuffixes(tag) {
+		if result.text == nil {
+			src, err := fs.ReadFile(m.fsys, name+suffix+".tmpl")
+			if err == nil {
+				result.text, err = texttemplate.New(name).Funcs(texttemplate.FuncMap(funcMap(nil, false))).Parse(string(src))
+				if err != nil {
+					return nil, errors.Wrapf(err, "parse error of %s%s.tmpl", name, suffix)
+				}
+			} else if !os.IsNotExist(err) {
+				return nil, errors.Wrapf(err, "can't read %s%s.tmpl", name, suffix)
+			}
+		}
+		if result.html == nil {
+			src, err := fs.ReadFile(m.fsys, name+suffix+".html.tmpl")
+			if err == nil {
+				result.html, err = htmltemplate.New(name).Funcs(FuncMap(nil)).Parse(string(src))
+				if err != nil {
+					return nil, errors.Wrapf(err, "parse error of %s%s.html.tmpl", name, suffix)
+				}
+			} else if !os.IsNotExist(err) {
+				return nil, errors.Wrapf(err, "can't read %s%s.html.tmpl", name, suffix)
+			}
+		}
+	}
+	if result.text == nil && result.html == nil {
+		return nil, errors.Errorf("template '%s' is not found", name)
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	// other goroutine may have loaded the same template during reading
+	if cached, ok := m.cache[cacheKey]; ok {
+		return cached, nil
+	}
+	m.cache[cacheKey] = result
+	return result, nil
+}
+
+/*
+Render renders message of specified language.
+
+It uses the registered translator that matches tag (see SelectTranslator).
+If no translator is registered, it uses default translator.
+*/
+func (m *MessageTemplates) Render(name string, tag language.Tag, data interface{}) (*Message, error) {
+	tmpl, err := m.load(name, tag)
+	if err != nil {
+		return nil, err
+	}
+	translator := SelectTranslator(tag.String())
+	result := &Message{Tag: tag}
+	if tmpl.text != nil {
+		text, err := tmpl.text.Clone()
+		if err != nil {
+			return nil, err
+		}
+		text.Funcs(texttemplate.FuncMap(funcMap(translator, false)))
+		var buffer bytes.Buffer
+		if text.Lookup("subject") != nil {
+			err = text.ExecuteTemplate(&buffer, "subject", data)
+			if err != nil {
+				return nil, err
+			}
+			result.Subject = buffer.String()
+			buffer.Reset()
+		}
+		err = text.Execute(&buffer, data)
+		if err != nil {
+			return nil, err
+		}
+		result.Text = buffer.String()
+	}
+	if tmpl.html != nil {
+		html, err := tmpl.html.Clone()
+		if err != nil {
+			return nil, err
+		}
+		html.Funcs(FuncMap(translator))
+		var buffer bytes.Buffer
+		err = html.Execute(&buffer, data)
+		if err != nil {
+			return nil, err
+		}
+		result.HTML = buffer.String()
+	}
+	return result, nil
+}
+
+/*
+RenderWithRequest renders message of the language that is selected by Accept-Language header of the request.
+*/
+func (m *MessageTemplates) RenderWithRequest(name string, r *http.Request, data interface{}) (*Message, error) {
+	return m.Render(name, SelectTag(r.Header.Get("Accept-Language")), data)
+}
@@ -0,0 +1,146 @@
+package i18n4v
+
+import (
+	"errors"
+	"golang.org/x/text/language"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestMessageTemplates(t *testing.T) {
+	MustAddFromString(`{
+        "values": {
+            "Welcome to %{service}": "%{service}へようこそ",
+            "Hello %{name}": "こんにちは %{name} さん"
+        }
+    }`, language.Japanese)
+	MustAddFromString(`{}`, language.English)
+	defer Reset()
+
+	templates := CreateMessageTemplates(fstest.MapFS{
+		"welcome.tmpl": &fstest.MapFile{Data: []byte(
+			`{{define "subject"}}{{t "Welcome to %{service}" (replace "service" .Service)}}{{end}}` +
+				`{{t "Hello %{name}" (replace "name" .Name)}}`)},
+		"welcome.html.tmpl": &fstest.MapFile{Data: []byte(
+			`<p>{{t "Hello %{name}" (replace "name" .Name)}}</p>`)},
+		"welcome.ja.html.tmpl": &fstest.MapFile{Data: []byte(
+			`<p lang="ja">{{t "Hello %{name}" (replace "name" .Name)}}</p>`)},
+	})
+	data := map[string]string{"Service": "i18n4v", "Name": "<Taro>"}
+
+	ja, err := templates.Render("welcome", language.MustParse("ja-JP"), data)
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	if ja.Subject != "i18n4vへようこそ" {
+		t.Errorf("Subject should be translated, but %s", ja.Subject)
+	}
+	if ja.Text != "こんにちは <Taro> さん" {
+		t.Errorf("Text should be translated without escaping, but %s", ja.Text)
+	}
+	if ja.HTML != `<p lang="ja">こんにちは &lt;Taro&gt; さん</p>` {
+		t.Errorf("HTML should use ja template and be escaped, but %s", ja.HTML)
+	}
+
+	en, err := templates.Render("welcome", language.English, data)
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	if en.Subject != "Welcome to i18n4v" || en.HTML != `<p>Hello &lt;Taro&gt;</p>` {
+		t.Errorf("It should fall back into default templates, but %s / %s", en.Subject, en.HTML)
+	}
+
+	if _, err := templates.Render("missing", language.English, data); err == nil {
+		t.Errorf("It should return error if template is missing")
+	}
+}
+
+// unreadableFS fails to open the file of name with permission error.
+type unreadableFS struct {
+	fsys fstest.MapFS
+	name string
+}
+
+func (f unreadableFS) Open(name string) (fs.File, error) {
+	if name == f.name {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.fsys.Open(name)
+}
+
+func TestMessageTemplatesReadError(t *testing.T) {
+	MustAddFromString(`{}`, language.English)
+	defer Reset()
+
+	templates := CreateMessageTemplates(unreadableFS{
+		fsys: fstest.MapFS{
+			"welcome.tmpl":    &fstest.MapFile{Data: []byte(`Hello`)},
+			"welcome.en.tmpl": &fstest.MapFile{Data: []byte(`Hello`)},
+		},
+		name: "welcome.en.tmpl",
+	})
+	if _, err := templates.Render("welcome", language.English, nil); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("It should return read error of template, but %v", err)
+	}
+}
+
+// blockingFS notifies opening the file of name via opened and blocks it until release is closed.
+type blockingFS struct {
+	fsys    fstest.MapFS
+	name    string
+	opened  chan struct{}
+	release chan struct{}
+}
+
+func (f blockingFS) Open(name string) (fs.File, error) {
+	if name == f.name {
+		f.opened <- struct{}{}
+		<-f.release
+	}
+	return f.fsys.Open(name)
+}
+
+func TestMessageTemplatesSlowRead(t *testing.T) {
+	MustAddFromString(`{}`, language.English)
+	defer Reset()
+
+	opened := make(chan struct{})
+	release := make(chan struct{})
+	templates := CreateMessageTemplates(blockingFS{
+		fsys: fstest.MapFS{
+			"welcome.tmpl": &fstest.MapFile{Data: []byte(`Welcome`)},
+			"goodbye.tmpl": &fstest.MapFile{Data: []byte(`Goodbye`)},
+		},
+		name:    "goodbye.tmpl",
+		opened:  opened,
+		release: release,
+	})
+	if _, err := templates.Render("welcome", language.English, nil); err != nil {
+		t.Fatal(err)
+	}
+	slow := make(chan error)
+	go func() {
+		_, err := templates.Render("goodbye", language.English, nil)
+		slow <- err
+	}()
+	<-opened
+	rendered := make(chan *Message)
+	go func() {
+		message, _ := templates.Render("welcome", language.English, nil)
+		rendered <- message
+	}()
+	select {
+	case message := <-rendered:
+		if message == nil || message.Text != "Welcome" {
+			t.Errorf("It should render cached template, but %v", message)
+		}
+	case <-time.After(time.Second):
+		t.Error("Cached template should be rendered while other template is being read")
+	}
+	close(release)
+	if err := <-slow; err != nil {
+		t.Errorf("It should render slow template: %v", err)
+	}
+}
@@ -0,0 +1,283 @@
+package i18n4v
+
+import (
+	"encoding/json"
+	"expvar"
+	"golang.org/x/text/language"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// LookupResult is a result of dictionary lookup in Translate.
+type LookupResult int
+
+const (
+	// Miss means the key is not in dictionaries. Default text or key is used.
+	Miss LookupResult = iota
+	// Hit means the key is found in root values of the first dictionary.
+	Hit
+	// ContextHit means the key is found in a context that matches.
+	ContextHit
+	// Fallback means the key is found in root values though contexts match,
+	// or it is found in the common namespace by a scoped translator.
+	Fallback
+)
+
+func (r LookupResult) String() string {
+	switch r {
+	case Hit:
+		return "hit"
+	case ContextHit:
+		return "context-hit"
+	case Fallback:
+		return "fallback"
+	}
+	return "miss"
+}
+
+// TranslateEvent describes a lookup in Translate.
+type TranslateEvent struct {
+	Tag       language.Tag
+	Namespace string
+	Key       string
+	Result    LookupResult
+}
+
+/*
+MetricsHook receives events of Translate. Implement it to send metrics to Prometheus, OpenTelemetry and so on.
+
+Translated is called synchronously in Translate from multiple goroutines, so it should be fast and thread safe.
+*/
+type MetricsHook interface {
+	Translated(event *TranslateEvent)
+}
+
+/*
+Counters are numbers of lookups in a locale.
+
+Hits includes all lookups that find translations. ContextHits and Fallbacks are parts of them.
+Misses are lookups that don't find translations.
+*/
+type Counters struct {
+	Hits        int64 `json:"hits"`
+	ContextHits int64 `json:"context_hits"`
+	Fallbacks   int64 `json:"fallbacks"`
+	Misses      int64 `json:"misses"`
+}
+
+/*
+Metrics collects counters of Translate per locale and passes events to hooks.
+
+Enable it for all translators with SetMetrics, or for a translator with Translator.SetMetrics.
+*/
+type Metrics struct {
+	mutex   sync.RWMutex
+	locales map[language.Tag]*Counters
+	hooks   []MetricsHook
+}
+
+// NewMetrics returns Metrics that passes events to hooks.
+func NewMetrics(hooks ...MetricsHook) *Metrics {
+	return &Metrics{
+		locales: make(map[language.Tag]*Counters),
+		hooks:   hooks,
+	}
+}
+
+func (m *Metrics) counters(tag language.Tag) *Counters {
+	m.mutex.RLock()
+	counters, ok := m.locales[tag]
+	m.mutex.RUnlock()
+	if ok {
+		return counters
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	counters, ok = m.locales[tag]
+	if !ok {
+		counters = &Counters{}
+		m.locales[tag] = counters
+	}
+	return counters
+}
+
+func (m *Metrics) record(event *TranslateEvent) {
+	counters := m.counters(event.Tag)
+	switch event.Result {
+	case Miss:
+		atomic.AddInt64(&counters.Misses, 1)
+	case ContextHit:
+		atomic.AddInt64(&counters.Hits, 1)
+		atomic.AddInt64(&counters.ContextHits, 1)
+	case Fallback:
+		atomic.AddInt64(&counters.Hits, 1)
+		atomic.AddInt64(&counters.Fallbacks, 1)
+	default:
+		atomic.AddInt64(&counters.Hits, 1)
+	}
+	for _, hook := range m.hooks {
+		hook.Translated(event)
+	}
+}
+
+/*
+Snapshot returns copy of counters per locale. Key is language tag like "ja" ("und" for translators without tag).
+*/
+func (m *Metrics) Snapshot() map[string]Counters {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	result := make(map[string]Counters, len(m.locales))
+	for tag, counters := range m.locales {
+		result[tag.String()] = Counters{
+			Hits:        atomic.LoadInt64(&counters.Hits),
+			ContextHits: atomic.LoadInt64(&counters.ContextHits),
+			Fallbacks:   atomic.LoadInt64(&counters.Fallbacks),
+			Misses:      atomic.LoadInt64(&counters.Misses),
+		}
+	}
+	return result
+}
+
+// String returns counters in JSON. It implements expvar.Var.
+func (m *Metrics) String() string {
+	data, _ := json.Marshal(m.Snapshot())
+	return string(data)
+}
+
+/*
+Publish exports counters via expvar with the name (like "i18n4v").
+They are shown in /debug/vars. It panics if the name is already used like expvar.Publish.
+*/
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, m)
+}
+
+// defaultMetrics keeps *Metrics that is set by SetMetrics.
+var defaultMetrics atomic.Value
+
+// metricsEnabled becomes 1 when metrics are set to any translator or globally.
+// Translate checks it first to skip looking up metrics while they are disabled.
+var metricsEnabled int32
+
+// loadMetrics returns *Metrics in v. It returns nil if it is not set.
+func loadMetrics(v *atomic.Value) *Metrics {
+	metrics, _ := v.Load().(*Metrics)
+	return metrics
+}
+
+/*
+SetMetrics enables metrics for all translators including default translator and registered translators.
+Translators that have their own Metrics (Translator.SetMetrics) use it instead.
+Passing nil disables metrics.
+*/
+func SetMetrics(metrics *Metrics) {
+	if metrics != nil {
+		atomic.StoreInt32(&metricsEnabled, 1)
+	}
+	defaultMetrics.Store(metrics)
+}
+
+/*
+SetMetrics method enables metrics only for the translator.
+Translators of its namespaces (Namespace method) and pseudo localisation (CreatePseudo) use it too.
+*/
+func (t *Translator) SetMetrics(metrics *Metrics) {
+	if metrics != nil {
+		atomic.StoreInt32(&metricsEnabled, 1)
+	}
+	t.metrics.Store(metrics)
+}
+
+// currentMetrics returns Metrics of the translator, its common namespace, wrapped translator or global one.
+func (t *Translator) currentMetrics() *Metrics {
+	if atomic.LoadInt32(&metricsEnabled) == 0 {
+		return nil
+	}
+	for _, source := range []*Translator{t, t.common, t.base} {
+		if source == nil {
+			continue
+		}
+		if metrics := loadMetrics(&source.metrics); metrics != nil {
+			return metrics
+		}
+	}
+	return loadMetrics(&defaultMetrics)
+}
+
+func (t *Translator) recordLookup(key string, result LookupResult) {
+	metrics := t.currentMetrics()
+	if metrics == nil {
+		return
+	}
+	metrics.record(&TranslateEvent{
+		Tag:       t.tag,
+		Namespace: t.namespace,
+		Key:       key,
+		Result:    result,
+	})
+}
+
+// usageKey identifies a logged key.
+type usageKey struct {
+	tag       language.Tag
+	namespace string
+	key       string
+}
+
+// usageRecord is a line of UsageLog.
+type usageRecord struct {
+	Locale    string `json:"locale"`
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key"`
+	Found     bool   `json:"found"`
+}
+
+/*
+UsageLog is a MetricsHook that writes keys used at runtime in JSON lines like:
+
+	{"locale":"ja","key":"Save","found":true}
+
+It records one of rate lookups (rate 1 records all of them), and each key is written only once.
+Pass the log to --usage-log option of i18n4vgo extract, coverage and prune commands to treat the keys as used.
+*/
+type UsageLog struct {
+	// counter is accessed atomically. It is the first field to keep 64-bit alignment.
+	counter int64
+	rate    int64
+	mutex   sync.Mutex
+	enc     *json.Encoder
+	written map[usageKey]bool
+}
+
+// NewUsageLog returns UsageLog that writes to w.
+func NewUsageLog(w io.Writer, rate int) *UsageLog {
+	if rate < 1 {
+		rate = 1
+	}
+	return &UsageLog{
+		enc:     json.NewEncoder(w),
+		rate:    int64(rate),
+		written: make(map[usageKey]bool),
+	}
+}
+
+// Translated writes the key if it is sampled and not written yet.
+func (l *UsageLog) Translated(event *TranslateEvent) {
+	if (atomic.AddInt64(&l.counter, 1)-1)%l.rate != 0 {
+		return
+	}
+	key := usageKey{tag: event.Tag, namespace: event.Namespace, key: event.Key}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.written[key] {
+		return
+	}
+	l.written[key] = true
+	l.enc.Encode(&usageRecord{
+		Locale:    event.Tag.String(),
+		Namespace: event.Namespace,
+		Key:       event.Key,
+		Found:     event.Result != Miss,
+	})
+}
@@ -0,0 +1,105 @@
+package i18n4v
+
+import (
+	"bytes"
+	"encoding/json"
+	"golang.org/x/text/language"
+	"strings"
+	"testing"
+)
+
+type recordingHook struct {
+	events []TranslateEvent
+}
+
+func (h *recordingHook) Translated(event *TranslateEvent) {
+	h.events = append(h.events, *event)
+}
+
+func TestMetrics(t *testing.T) {
+	ja := MustCreateFromString(`{
+        "values": {
+            "Save": "保存",
+            "Hello": "こんにちは"
+        },
+        "contexts": [
+            {
+                "matches": {"gender": "female"},
+                "values": {"Hello": "こんにちは(女性)"}
+            }
+        ],
+        "namespaces": {
+            "billing": {"values": {"Pay": "支払う"}}
+        }
+    }`, language.Japanese)
+	hook := &recordingHook{}
+	metrics := NewMetrics(hook)
+	ja.SetMetrics(metrics)
+
+	ja.Translate("Save")
+	ja.Translate("Hello", Replace{}, Context{"gender": "female"})
+	ja.Translate("Save", Replace{}, Context{"gender": "female"})
+	ja.Translate("Unknown")
+	billing := ja.Namespace("billing")
+	billing.Translate("Pay")
+	billing.Translate("Save")
+
+	counters := metrics.Snapshot()["ja"]
+	if counters.Hits != 5 || counters.ContextHits != 1 || counters.Fallbacks != 2 || counters.Misses != 1 {
+		t.Errorf("It should count lookups, but %+v", counters)
+	}
+	if len(hook.events) != 6 {
+		t.Fatalf("It should pass all events to hook, but %d", len(hook.events))
+	}
+	if e := hook.events[5]; e.Namespace != "billing" || e.Key != "Save" || e.Result != Fallback {
+		t.Errorf("It should pass namespace and result to hook, but %+v", e)
+	}
+	var exported map[string]Counters
+	if err := json.Unmarshal([]byte(metrics.String()), &exported); err != nil || exported["ja"].Misses != 1 {
+		t.Errorf("It should export counters in JSON for expvar, but %s, %v", metrics.String(), err)
+	}
+}
+
+func TestGlobalMetrics(t *testing.T) {
+	en := MustCreateFromString(`{"values": {"Save": "Save"}}`, language.English)
+	metrics := NewMetrics()
+	SetMetrics(metrics)
+	defer SetMetrics(nil)
+	en.Translate("Save")
+	CreatePseudo(en, nil).Translate("Save")
+	if counters := metrics.Snapshot()["en"]; counters.Hits != 2 {
+		t.Errorf("It should count lookups of all translators, but %+v", counters)
+	}
+	own := NewMetrics()
+	en.SetMetrics(own)
+	en.Translate("Save")
+	if metrics.Snapshot()["en"].Hits != 2 || own.Snapshot()["en"].Hits != 1 {
+		t.Error("Translator's own metrics should be used instead of global one")
+	}
+}
+
+func TestUsageLog(t *testing.T) {
+	ja := MustCreateFromString(`{"values": {"Save": "保存"}}`, language.Japanese)
+	var buffer bytes.Buffer
+	ja.SetMetrics(NewMetrics(NewUsageLog(&buffer, 1)))
+	ja.Translate("Save")
+	ja.Translate("Save")
+	ja.Translate("Unknown")
+	ja.Namespace("billing").Translate("Save")
+	expected := `{"locale":"ja","key":"Save","found":true}
+{"locale":"ja","key":"Unknown","found":false}
+{"locale":"ja","namespace":"billing","key":"Save","found":true}
+`
+	if buffer.String() != expected {
+		t.Errorf("It should write each key once, but %s", buffer.String())
+	}
+
+	buffer.Reset()
+	sampled := NewUsageLog(&buffer, 2)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		sampled.Translated(&TranslateEvent{Tag: language.Japanese, Key: key, Result: Hit})
+	}
+	if lines := strings.Count(buffer.String(), "\n"); lines != 2 {
+		t.Errorf("It should sample lookups, but %d lines", lines)
+	}
+}
@@ -0,0 +1,117 @@
+package i18n4v
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"sort"
+)
+
+// singleTag returns the tag of optional tag parameter of Create and Add functions.
+func singleTag(tag []language.Tag) (language.Tag, bool, error) {
+	switch len(tag) {
+	case 0:
+		return language.Und, false, nil
+	case 1:
+		return tag[0], true, nil
+	}
+	return language.Und, false, errors.New("Only one tag is acceptable")
+}
+
+// namespaceDictionary returns dictionary of the namespace. If it doesn't exist, it is created.
+// Caller should lock the mutex of t.
+func (t *Translator) namespaceDictionary(name string) *Translator {
+	if name == "" {
+		return t
+	}
+	result, ok := t.namespaces[name]
+	if !ok {
+		result = &Translator{
+			values:        make(map[string]*translation),
+			globalContext: t.globalContext,
+			tag:           t.tag,
+			direction:     t.direction,
+		}
+		if t.namespaces == nil {
+			t.namespaces = make(map[string]*Translator)
+		}
+		t.namespaces[name] = result
+	}
+	return result
+}
+
+/*
+Namespace method returns translator that is scoped to the namespace.
+
+It searches the namespace first and then the common namespace (the dictionary that is registered by Add or Create).
+Editing methods like AddWord modify the dictionary of the namespace.
+If the namespace doesn't exist yet, it is created. An empty name returns translator of the common namespace.
+*/
+func (t *Translator) Namespace(name string) *Translator {
+	root := t.namespaceRoot()
+	if name == "" {
+		return t.scoped(root, "", root)
+	}
+	root.mutex.Lock()
+	base := root.namespaceDictionary(name)
+	root.mutex.Unlock()
+	return t.scoped(root, name, base)
+}
+
+/*
+LookupNamespace method returns translator that is scoped to the namespace like Namespace method.
+
+Unlike Namespace method, it doesn't create the namespace. If the namespace doesn't exist, it returns false.
+It is good for names that come from outside like query parameters.
+*/
+func (t *Translator) LookupNamespace(name string) (*Translator, bool) {
+	root := t.namespaceRoot()
+	if name == "" {
+		return t.scoped(root, "", root), true
+	}
+	root.mutex.RLock()
+	base, ok := root.namespaces[name]
+	root.mutex.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return t.scoped(root, name, base), true
+}
+
+// namespaceRoot returns translator of the common namespace.
+func (t *Translator) namespaceRoot() *Translator {
+	if t.common != nil {
+		return t.common
+	}
+	return t.source()
+}
+
+// scoped returns translator that searches base and then root (the common namespace).
+func (t *Translator) scoped(root *Translator, name string, base *Translator) *Translator {
+	result := &Translator{
+		globalContext: root.globalContext,
+		tag:           t.tag,
+		direction:     t.direction,
+		filter:        t.filter,
+		base:          base,
+	}
+	if name != "" {
+		result.common = root
+		result.namespace = name
+	}
+	return result
+}
+
+/*
+Namespaces method returns names of namespaces in sorted order.
+*/
+func (t *Translator) Namespaces() []string {
+	root := t.namespaceRoot()
+	root.mutex.RLock()
+	defer root.mutex.RUnlock()
+	result := make([]string, 0, len(root.namespaces))
+	for name := range root.namespaces {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,134 @@
+package i18n4v
+
+import (
+	"encoding/json"
+	"golang.org/x/text/language"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	ja := MustCreateFromString(`{
+        "values": {
+            "Save": "保存",
+            "Cancel": "キャンセル"
+        }
+    }`, language.Japanese)
+	billing := ja.Namespace("billing")
+	if err := billing.add(strings.NewReader(`{"values": {"Save": "支払う"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if result := billing.Translate("Save"); result != "支払う" {
+		t.Errorf("It should use translation of the namespace, but '%s'", result)
+	}
+	if result := billing.Translate("Cancel"); result != "キャンセル" {
+		t.Errorf("It should fall back to the common namespace, but '%s'", result)
+	}
+	if result := billing.Translate("Unknown"); result != "Unknown" {
+		t.Errorf("It should use original text, but '%s'", result)
+	}
+	if result := ja.Namespace("other").Translate("Save"); result != "保存" {
+		t.Errorf("Other namespace should use common namespace, but '%s'", result)
+	}
+	if names := ja.Namespaces(); !reflect.DeepEqual(names, []string{"billing", "other"}) {
+		t.Errorf("It should return namespaces, but %v", names)
+	}
+	if tag := billing.Tag(); tag != language.Japanese {
+		t.Errorf("Scoped translator should keep tag, but %v", tag)
+	}
+}
+
+func TestLookupNamespace(t *testing.T) {
+	ja := MustCreateFromString(`{"values": {"Save": "保存"}}`, language.Japanese)
+	ja.Namespace("billing").add(strings.NewReader(`{"values": {"Save": "支払う"}}`))
+	if billing, ok := ja.LookupNamespace("billing"); !ok || billing.Translate("Save") != "支払う" {
+		t.Errorf("It should return existing namespace, but %v", ok)
+	}
+	if _, ok := ja.LookupNamespace("unknown"); ok {
+		t.Error("It should not return unknown namespace")
+	}
+	if names := ja.Namespaces(); !reflect.DeepEqual(names, []string{"billing"}) {
+		t.Errorf("It should not create namespace, but %v", names)
+	}
+}
+
+func TestCreateInNamespace(t *testing.T) {
+	ja, err := CreateInNamespace("billing", strings.NewReader(`{"values": {"Save": "支払う"}}`), language.Japanese)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := ja.Translate("Save"); result != "Save" {
+		t.Errorf("Common namespace should not have the key, but '%s'", result)
+	}
+	if result := ja.Namespace("billing").Translate("Save"); result != "支払う" {
+		t.Errorf("It should use translation of the namespace, but '%s'", result)
+	}
+	if _, err := CreateInNamespace("billing", strings.NewReader(`{}`), language.Japanese, language.English); err == nil {
+		t.Error("It should return error for several tags")
+	}
+}
+
+func TestNamespaceInJSON(t *testing.T) {
+	source := `{
+        "values": {"Save": "保存"},
+        "namespaces": {
+            "billing": {
+                "values": {"Save": "支払う"},
+                "contexts": [
+                    {"matches": {"plan": "free"}, "values": {"Save": "無料で始める"}}
+                ]
+            }
+        }
+    }`
+	ja := MustCreateFromString(source, language.Japanese)
+	billing := ja.Namespace("billing")
+	if result := billing.Translate("Save", Replace{}, Context{"plan": "free"}); result != "無料で始める" {
+		t.Errorf("It should use context of the namespace, but '%s'", result)
+	}
+	if entry, ok := billing.Lookup("Save"); !ok || entry.Translation != "支払う" {
+		t.Errorf("Lookup should search the namespace, but %v", entry)
+	}
+
+	data, err := json.Marshal(ja)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected, actual interface{}
+	json.Unmarshal([]byte(source), &expected)
+	json.Unmarshal(data, &actual)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("It should keep namespaces in JSON, but %s", string(data))
+	}
+	data, _ = json.Marshal(billing)
+	if strings.Contains(string(data), "保存") {
+		t.Errorf("Scoped translator should export only the namespace, but %s", string(data))
+	}
+
+	_, err = CreateFromString(`{"namespaces": {"a": {"namespaces": {"b": {}}}}}`)
+	if err == nil {
+		t.Error("It should return error for nested namespaces")
+	}
+}
+
+func TestAddWithNamespace(t *testing.T) {
+	Reset()
+	defer Reset()
+	tags := []language.Tag{language.Japanese}
+	MustAddFromString(`{"values": {"Save": "保存"}}`, tags...)
+	MustAddToNamespace("billing", strings.NewReader(`{"values": {"Save": "支払う"}}`), language.Japanese)
+	translator := SelectTranslator("ja")
+	if result := translator.Translate("Save"); result != "保存" {
+		t.Errorf("It should use common namespace, but '%s'", result)
+	}
+	if result := translator.Namespace("billing").Translate("Save"); result != "支払う" {
+		t.Errorf("It should use namespace, but '%s'", result)
+	}
+
+	w := httptest.NewRecorder()
+	NewDictionaryHandler().ServeHTTP(w, httptest.NewRequest("GET", "/?lang=ja&namespace=billing", nil))
+	if body := w.Body.String(); !strings.Contains(body, "支払う") || strings.Contains(body, "保存") {
+		t.Errorf("Handler should serve the namespace, but %s", body)
+	}
+}
@@ -0,0 +1,136 @@
+package i18n4v
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+Provider translates texts to make draft translations (like machine translation services).
+
+Translate receives texts in source language and returns translations in the same order.
+It can return an empty string for a text that it can't translate.
+Placeholders in texts are replaced with tokens like "{0}" before they are passed (see ProtectPlaceholders),
+and providers should keep the tokens in translations.
+*/
+type Provider interface {
+	Translate(ctx context.Context, source, target language.Tag, texts []string) ([]string, error)
+}
+
+// placeholderTokenPattern matches tokens that ProtectPlaceholders makes.
+var placeholderTokenPattern = regexp.MustCompile(`\{[0-9]+\}`)
+
+// protectedPattern matches placeholders and literal texts that look like tokens.
+var protectedPattern = regexp.MustCompile(`-?%n|%\{[^}]+\}|\{[0-9]+\}`)
+
+/*
+ProtectPlaceholders replaces placeholders (%n, -%n and %{key}) in text with numbered tokens like "{0}".
+
+It returns the protected text and placeholders in token order. Same placeholders share a token.
+Literal texts like "{0}" in text are replaced with tokens too, so that they are not confused with tokens.
+Use RestorePlaceholders to put them back.
+*/
+func ProtectPlaceholders(text string) (string, []string) {
+	var placeholders []string
+	tokens := make(map[string]string)
+	protected := protectedPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		token, ok := tokens[placeholder]
+		if !ok {
+			token = "{" + strconv.Itoa(len(placeholders)) + "}"
+			tokens[placeholder] = token
+			placeholders = append(placeholders, placeholder)
+		}
+		return token
+	})
+	return protected, placeholders
+}
+
+/*
+RestorePlaceholders replaces tokens that ProtectPlaceholders makes with placeholders.
+
+If some tokens are lost or unknown tokens are found, it returns error.
+*/
+func RestorePlaceholders(text string, placeholders []string) (string, error) {
+	found := make([]bool, len(placeholders))
+	var err error
+	restored := placeholderTokenPattern.ReplaceAllStringFunc(text, func(token string) string {
+		index, _ := strconv.Atoi(token[1 : len(token)-1])
+		if index >= len(placeholders) {
+			err = errors.Errorf("unknown placeholder token %s in '%s'", token, text)
+			return token
+		}
+		found[index] = true
+		return placeholders[index]
+	})
+	if err != nil {
+		return "", err
+	}
+	var lost []string
+	for i, ok := range found {
+		if !ok {
+			lost = append(lost, placeholders[i])
+		}
+	}
+	if len(lost) > 0 {
+		return "", errors.Errorf("placeholders %s are lost in '%s'", strings.Join(lost, ", "), text)
+	}
+	return restored, nil
+}
+
+/*
+DraftTranslations translates texts with provider and protects placeholders in them.
+
+The result has the same length as texts. Translations that lose placeholders are dropped
+(they are returned as empty strings) because they break formatting at runtime.
+*/
+func DraftTranslations(ctx context.Context, provider Provider, source, target language.Tag, texts []string) ([]string, error) {
+	protected := make([]string, len(texts))
+	placeholders := make([][]string, len(texts))
+	for i, text := range texts {
+		protected[i], placeholders[i] = ProtectPlaceholders(text)
+	}
+	translations, err := provider.Translate(ctx, source, target, protected)
+	if err != nil {
+		return nil, err
+	}
+	if len(translations) != len(texts) {
+		return nil, errors.Errorf("provider returns %d translations for %d texts", len(translations), len(texts))
+	}
+	result := make([]string, len(texts))
+	for i, translation := range translations {
+		if translation == "" {
+			continue
+		}
+		if restored, err := RestorePlaceholders(translation, placeholders[i]); err == nil {
+			result[i] = restored
+		}
+	}
+	return result, nil
+}
+
+/*
+PseudoProvider is a Provider that returns pseudo localised texts like "[Ŝåṽé ~~]".
+
+It works offline and always returns the same result, so it is good for testing translation pipelines.
+If Options is nil, DefaultPseudoOptions is used.
+*/
+type PseudoProvider struct {
+	Options *PseudoOptions
+}
+
+// Translate converts texts into pseudo localised texts. Tokens of placeholders are kept as is.
+func (p *PseudoProvider) Translate(ctx context.Context, source, target language.Tag, texts []string) ([]string, error) {
+	options := p.Options
+	if options == nil {
+		options = &DefaultPseudoOptions
+	}
+	result := make([]string, len(texts))
+	for i, text := range texts {
+		result[i] = pseudoLocalize(text, options)
+	}
+	return result, nil
+}
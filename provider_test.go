@@ -0,0 +1,71 @@
+package i18n4v
+
+import (
+	"context"
+	"golang.org/x/text/language"
+	"strings"
+	"testing"
+)
+
+func TestProtectPlaceholders(t *testing.T) {
+	protected, placeholders := ProtectPlaceholders("%{name} has %n files (-%n, %{name})")
+	if protected != "{0} has {1} files ({2}, {0})" {
+		t.Errorf("It should replace placeholders with tokens, but %s", protected)
+	}
+	if len(placeholders) != 3 || placeholders[0] != "%{name}" || placeholders[1] != "%n" || placeholders[2] != "-%n" {
+		t.Errorf("It should return placeholders in token order, but %v", placeholders)
+	}
+	restored, err := RestorePlaceholders("{1} files of {0} ({2}, {0})", placeholders)
+	if err != nil || restored != "%n files of %{name} (-%n, %{name})" {
+		t.Errorf("It should restore placeholders in reordered text, but %s, %v", restored, err)
+	}
+	if _, err := RestorePlaceholders("{0} files", placeholders); err == nil {
+		t.Error("It should return error if placeholders are lost")
+	}
+	if _, err := RestorePlaceholders("{0} {1} {2} {3}", placeholders); err == nil {
+		t.Error("It should return error for unknown tokens")
+	}
+}
+
+func TestProtectLiteralTokens(t *testing.T) {
+	protected, placeholders := ProtectPlaceholders("Use {0} for %{name}")
+	if protected != "Use {0} for {1}" || len(placeholders) != 2 || placeholders[0] != "{0}" || placeholders[1] != "%{name}" {
+		t.Errorf("Literal token should be protected too, but %s, %v", protected, placeholders)
+	}
+	restored, err := RestorePlaceholders("{1} には {0} を使う", placeholders)
+	if err != nil || restored != "%{name} には {0} を使う" {
+		t.Errorf("It should restore literal token as is, but %s, %v", restored, err)
+	}
+}
+
+// brokenProvider drops tokens of texts that have more than one placeholder.
+type brokenProvider struct{}
+
+func (p *brokenProvider) Translate(ctx context.Context, source, target language.Tag, texts []string) ([]string, error) {
+	result := make([]string, len(texts))
+	for i, text := range texts {
+		result[i] = strings.ToUpper(strings.Replace(text, "{1}", "", -1))
+	}
+	return result, nil
+}
+
+func TestDraftTranslations(t *testing.T) {
+	texts := []string{"Save", "%n comments by %{name}", "Hello %{name}"}
+	result, err := DraftTranslations(context.Background(), &PseudoProvider{}, language.English, PseudoLocale, texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result[0] != "[Šåṽé ~~]" {
+		t.Errorf("It should return pseudo text, but %s", result[0])
+	}
+	if result[1] != "[%n çöɱɱéñţš ƀý %{name} ~~~~~~]" {
+		t.Errorf("It should keep placeholders, but %s", result[1])
+	}
+	result, err = DraftTranslations(context.Background(), &brokenProvider{}, language.English, language.Japanese, texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result[0] != "SAVE" || result[1] != "" || result[2] != "HELLO %{name}" {
+		t.Errorf("It should drop translations that lose placeholders, but %v", result)
+	}
+}
@@ -0,0 +1,128 @@
+package i18n4v
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"regexp"
+	"strings"
+)
+
+// PseudoLocale is a language tag for pseudo localisation.
+var PseudoLocale = language.MustParse("en-XA")
+
+/*
+PseudoOptions configures pseudo localisation.
+
+Expansion is a percentage of padding length that simulates longer translations.
+Prefix and Suffix are markers to find truncated or concatenated texts.
+*/
+type PseudoOptions struct {
+	Expansion int
+	Prefix    string
+	Suffix    string
+	NoAccent  bool
+}
+
+// DefaultPseudoOptions is used when nil is passed as options.
+var DefaultPseudoOptions = PseudoOptions{
+	Expansion: 30,
+	Prefix:    "[",
+	Suffix:    "]",
+}
+
+var pseudoAccents = map[rune]rune{
+	'A': 'Å', 'B': 'Ɓ', 'C': 'Ç', 'D': 'Đ', 'E': 'É', 'F': 'Ƒ', 'G': 'Ĝ', 'H': 'Ĥ', 'I': 'Î',
+	'J': 'Ĵ', 'K': 'Ķ', 'L': 'Ļ', 'M': 'Ṁ', 'N': 'Ñ', 'O': 'Ö', 'P': 'Þ', 'Q': 'Ǫ', 'R': 'Ŕ',
+	'S': 'Š', 'T': 'Ţ', 'U': 'Û', 'V': 'Ṽ', 'W': 'Ŵ', 'X': 'Ẋ', 'Y': 'Ý', 'Z': 'Ž',
+	'a': 'å', 'b': 'ƀ', 'c': 'ç', 'd': 'ð', 'e': 'é', 'f': 'ƒ', 'g': 'ĝ', 'h': 'ĥ', 'i': 'î',
+	'j': 'ĵ', 'k': 'ķ', 'l': 'ļ', 'm': 'ɱ', 'n': 'ñ', 'o': 'ö', 'p': 'þ', 'q': 'ǫ', 'r': 'ŕ',
+	's': 'š', 't': 'ţ', 'u': 'û', 'v': 'ṽ', 'w': 'ŵ', 'x': 'ẋ', 'y': 'ý', 'z': 'ž',
+}
+
+const pseudoPadding = "~"
+
+// pseudoPlaceholderPattern matches placeholders that should be kept as is.
+var pseudoPlaceholderPattern = regexp.MustCompile(`-?%n|%\{[^}]+\}`)
+
+// pseudoLocalize converts text into pseudo localised text.
+func pseudoLocalize(text string, options *PseudoOptions) string {
+	var result []string
+	length := 0
+	convert := func(src string) {
+		if options.NoAccent {
+			result = append(result, src)
+		} else {
+			result = append(result, strings.Map(func(r rune) rune {
+				if accent, ok := pseudoAccents[r]; ok {
+					return accent
+				}
+				return r
+			}, src))
+		}
+		length += len([]rune(src))
+	}
+	last := 0
+	for _, loc := range pseudoPlaceholderPattern.FindAllStringIndex(text, -1) {
+		convert(text[last:loc[0]])
+		result = append(result, text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	convert(text[last:])
+	if padding := (length*options.Expansion + 99) / 100; padding > 0 {
+		result = append(result, " ", strings.Repeat(pseudoPadding, padding))
+	}
+	return options.Prefix + strings.Join(result, "") + options.Suffix
+}
+
+/*
+CreatePseudo returns new Translator instance that wraps passed translator and
+converts its translations into pseudo localised text like "[Ŝåṽé ~~]".
+
+Placeholders (%n, -%n and %{key}) are kept as is. It is good for finding hard-coded strings,
+truncation and concatenation problems without real translations.
+If options is nil, DefaultPseudoOptions is used.
+*/
+func CreatePseudo(t *Translator, options *PseudoOptions) *Translator {
+	if options == nil {
+		options = &DefaultPseudoOptions
+	}
+	opts := *options
+	result := &Translator{
+		globalContext: t.source().globalContext,
+		base:          t.source(),
+		common:        t.common,
+		filter: func(text string) string {
+			return pseudoLocalize(text, &opts)
+		},
+	}
+	result.setTag(t.tag)
+	return result
+}
+
+/*
+AddPseudo registers pseudo localisation translator (like en-XA) that wraps
+the registered translator of source language.
+
+You can access the registered translator with Select, SelectTranslator
+SelectTranslatorWithRequest, SelectWithRequest functions like other languages.
+*/
+func AddPseudo(tag, source language.Tag, options *PseudoOptions) error {
+	translator, err := registeredTranslator(source)
+	if err != nil {
+		return err
+	}
+	if translator == nil {
+		return errors.New("Specified source tag is not registered")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, ok := translators[tag]; !ok {
+		languages = append(languages, tag)
+	}
+	pseudo := CreatePseudo(translator, options)
+	pseudo.setTag(tag)
+	translators[tag] = pseudo
+	matcher = nil
+	return nil
+}
@@ -0,0 +1,51 @@
+package i18n4v
+
+import (
+	"golang.org/x/text/language"
+	"testing"
+)
+
+func TestPseudo(t *testing.T) {
+	en := MustCreateFromString(`{
+        "values": {
+            "%n comments": [
+                [0, 0, "No comments"],
+                [1, null, "%n comments by %{name}"]
+            ]
+        }
+    }`)
+	pseudo := CreatePseudo(en, nil)
+	if r := pseudo.Translate("Save"); r != "[Šåṽé ~~]" {
+		t.Errorf("It should be converted into pseudo text, but %s", r)
+	}
+	if r := pseudo.Translate("%n comments", 3, Replace{"name": "John"}); r != "[3 çöɱɱéñţš ƀý John ~~~~]" {
+		t.Errorf("It should keep placeholders, but %s", r)
+	}
+	noPadding := CreatePseudo(en, &PseudoOptions{Prefix: "<", Suffix: ">", NoAccent: true})
+	if r := noPadding.Translate("%n comments", 0); r != "<No comments>" {
+		t.Errorf("It should use options, but %s", r)
+	}
+	en.AddWord("Cancel", "Cancel!")
+	if r := pseudo.Translate("Cancel"); r != "[Çåñçéļ! ~~~]" {
+		t.Errorf("It should use words added to wrapped translator, but %s", r)
+	}
+}
+
+func TestAddPseudo(t *testing.T) {
+	MustAddFromString(`{
+        "values": {
+            "Cancel": "Cancel"
+        }
+    }`, language.English)
+	defer Reset()
+	err := AddPseudo(PseudoLocale, language.English, nil)
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	if r := Select("en-XA")("Cancel"); r != "[Çåñçéļ ~~]" {
+		t.Errorf("It should select pseudo locale, but %s", r)
+	}
+	if err := AddPseudo(PseudoLocale, language.French, nil); err == nil {
+		t.Errorf("It should return error if source language is not registered")
+	}
+}
@@ -5,19 +5,38 @@ import (
 	"net/http"
 )
 
+/*
+SelectTag returns the registered language tag that matches lang.
+lang is a list of preferred languages like Accept-Language header.
+
+If no language is registered, it returns language.Und.
+*/
+func SelectTag(lang string) language.Tag {
+	lock.Lock()
+	defer lock.Unlock()
+	if len(languages) == 0 {
+		return language.Und
+	}
+	if matcher == nil {
+		matcher = language.NewMatcher(languages)
+	}
+	// returned tag may have extensions like "en-u-rg-uszzzz". Use index to get registered one.
+	_, index := language.MatchStrings(matcher, lang)
+	return languages[index]
+}
+
 /*
 SelectTranslator returns Translator instance from registered ones.
+
+If the selected locale is registered by AddLoader, it is loaded at the first time.
+If loading fails, it returns default translator (use Preload to check errors).
 */
 func SelectTranslator(lang string) *Translator {
-	{
-		lock.Lock()
-		defer lock.Unlock()
-		if matcher == nil {
-			matcher = language.NewMatcher(languages)
-		}
+	translator, err := registeredTranslator(SelectTag(lang))
+	if err != nil {
+		return defaultTranslator
 	}
-	tag, _ := language.MatchStrings(matcher, lang)
-	return translators[tag]
+	return translator
 }
 
 func SelectTranslatorWithRequest(r *http.Request) *Translator {
@@ -25,15 +44,7 @@ func SelectTranslatorWithRequest(r *http.Request) *Translator {
 }
 
 func Select(lang string) TranslatorFunction {
-	{
-		lock.Lock()
-		defer lock.Unlock()
-		if matcher == nil {
-			matcher = language.NewMatcher(languages)
-		}
-	}
-	tag, _ := language.MatchStrings(matcher, lang)
-	translator := translators[tag]
+	translator := SelectTranslator(lang)
 	return func(text string, args ...interface{}) string {
 		return translator.Translate(text, args...)
 	}
@@ -31,3 +31,20 @@ func TestSelectLanguageCode(t *testing.T) {
 		t.Errorf("Translation error: %s", __("Cancel"))
 	}
 }
+
+func TestSelectTagWithRegion(t *testing.T) {
+	MustAddFromString("{}", language.English)
+	MustAddFromString(`{
+        "values": {
+            "Cancel": "キャンセル"
+        }
+    }`, language.Japanese)
+	defer Reset()
+
+	if tag := SelectTag("ja-JP,en;q=0.5"); tag != language.Japanese {
+		t.Errorf("It should return registered tag, but %s", tag)
+	}
+	if r := Select("ja-JP")("Cancel"); r != "キャンセル" {
+		t.Errorf("Translation error: %s", r)
+	}
+}
@@ -0,0 +1,386 @@
+package i18n4v
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Source is a storage of dictionaries like directories, embed.FS or databases.
+
+It is registered by AddSource. Dictionaries are loaded lazily like AddLoader.
+*/
+type Source interface {
+	// Locales returns language tags of dictionaries in the source.
+	Locales() ([]language.Tag, error)
+	// Load returns JSON dictionary of the locale.
+	Load(tag language.Tag) (io.Reader, error)
+}
+
+/*
+WatchableSource is a Source that can notify modifications of dictionaries. It is used by WatchSource.
+*/
+type WatchableSource interface {
+	Source
+	// Watch calls changed when dictionary of the locale is added or modified, and calls removed when it is removed.
+	// It blocks until ctx is done.
+	Watch(ctx context.Context, changed, removed func(tag language.Tag)) error
+}
+
+/*
+AddSource registers all locales in source. They are loaded when they are used at the first time (see AddLoader).
+*/
+func AddSource(source Source) error {
+	tags, err := source.Locales()
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		err = AddLoader(tag, sourceLoader(source, tag))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sourceLoader(source Source, tag language.Tag) Loader {
+	return func() (io.Reader, error) {
+		return source.Load(tag)
+	}
+}
+
+/*
+WatchSource watches modifications of source until ctx is done.
+
+Modified locales are unloaded and loaded again when they are used next time.
+Added locales are registered and removed locales are unregistered.
+If source doesn't implement WatchableSource, it returns error.
+*/
+func WatchSource(ctx context.Context, source Source) error {
+	watchable, ok := source.(WatchableSource)
+	if !ok {
+		return errors.New("Source doesn't support watching")
+	}
+	return watchable.Watch(ctx, func(tag language.Tag) {
+		lock.Lock()
+		defer lock.Unlock()
+		if _, ok := loaders[tag]; !ok {
+			if _, ok := translators[tag]; ok {
+				// registered by Add. It can't be reloaded.
+				return
+			}
+			loaders[tag] = &lazyLocale{loader: sourceLoader(source, tag)}
+			languages = append(languages, tag)
+			matcher = nil
+			return
+		}
+		delete(translators, tag)
+	}, func(tag language.Tag) {
+		lock.Lock()
+		defer lock.Unlock()
+		if _, ok := loaders[tag]; !ok {
+			// registered by Add. It is kept.
+			return
+		}
+		delete(loaders, tag)
+		delete(translators, tag)
+		for i, registered := range languages {
+			if registered == tag {
+				languages = append(languages[:i], languages[i+1:]...)
+				break
+			}
+		}
+		matcher = nil
+	})
+}
+
+type fsSource struct {
+	fsys fs.FS
+	dir  string
+	lock sync.Mutex
+	// files keeps file names of locales that are found by Locales.
+	files map[language.Tag]string
+}
+
+/*
+FSSource returns Source that reads "<locale>.json" files (like "ja.json" and "pt-BR.json") in dir of fsys.
+It accepts any fs.FS like embed.FS:
+
+	//go:embed locales
+	var locales embed.FS
+
+	i18n4v.AddSource(i18n4v.FSSource(locales, "locales"))
+*/
+func FSSource(fsys fs.FS, dir string) Source {
+	return &fsSource{
+		fsys:  fsys,
+		dir:   dir,
+		files: make(map[language.Tag]string),
+	}
+}
+
+func (s *fsSource) Locales() ([]language.Tag, error) {
+	entries, err := fs.ReadDir(s.fsys, s.dir)
+	if err != nil {
+		return nil, err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.files = make(map[language.Tag]string)
+	var result []language.Tag
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".json" {
+			continue
+		}
+		tag, err := language.Parse(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+		s.files[tag] = name
+		result = append(result, tag)
+	}
+	return result, nil
+}
+
+func (s *fsSource) Load(tag language.Tag) (io.Reader, error) {
+	s.lock.Lock()
+	name, ok := s.files[tag]
+	s.lock.Unlock()
+	if !ok {
+		name = tag.String() + ".json"
+	}
+	data, err := fs.ReadFile(s.fsys, path.Join(s.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+type dirSource struct {
+	*fsSource
+	root     string
+	interval time.Duration
+}
+
+/*
+DirSource returns WatchableSource that reads "<locale>.json" files in dir of the file system.
+
+Watch checks modification times of files at each interval.
+If interval is 0, it checks every second.
+*/
+func DirSource(dir string, interval time.Duration) WatchableSource {
+	if interval == 0 {
+		interval = time.Second
+	}
+	return &dirSource{
+		fsSource: FSSource(os.DirFS(dir), ".").(*fsSource),
+		root:     dir,
+		interval: interval,
+	}
+}
+
+// modTimes returns modification times of dictionaries. It returns error if the directory can't be read.
+func (s *dirSource) modTimes() (map[language.Tag]time.Time, error) {
+	result := make(map[language.Tag]time.Time)
+	tags, err := s.Locales()
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		s.lock.Lock()
+		name := s.files[tag]
+		s.lock.Unlock()
+		if info, err := os.Stat(filepath.Join(s.root, name)); err == nil {
+			result[tag] = info.ModTime()
+		}
+	}
+	return result, nil
+}
+
+func (s *dirSource) Watch(ctx context.Context, changed, removed func(tag language.Tag)) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	last, err := s.modTimes()
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			current, err := s.modTimes()
+			if err != nil {
+				// the directory may be replaced now. Check it again next time.
+				continue
+			}
+			for tag, modified := range current {
+				if previous, ok := last[tag]; !ok || !previous.Equal(modified) {
+					changed(tag)
+				}
+			}
+			for tag := range last {
+				if _, ok := current[tag]; !ok {
+					removed(tag)
+				}
+			}
+			last = current
+		}
+	}
+}
+
+/*
+MapSource is a Source that keeps JSON dictionaries in memory. It is good for tests and generated dictionaries.
+
+	i18n4v.AddSource(i18n4v.MapSource{
+	    language.Japanese: `{"values": {"Hello": "こんにちは"}}`,
+	})
+*/
+type MapSource map[language.Tag]string
+
+func (s MapSource) Locales() ([]language.Tag, error) {
+	result := make([]language.Tag, 0, len(s))
+	for tag := range s {
+		result = append(result, tag)
+	}
+	return result, nil
+}
+
+func (s MapSource) Load(tag language.Tag) (io.Reader, error) {
+	dictionary, ok := s[tag]
+	if !ok {
+		return nil, errors.Errorf("dictionary of '%s' is not found", tag)
+	}
+	return strings.NewReader(dictionary), nil
+}
+
+/*
+SQLSource is a Source that reads translations from a table via database/sql.
+
+Each row of the table keeps one translation of root values:
+
+	CREATE TABLE translations (locale TEXT, name TEXT, translation TEXT);
+
+Translations are plain strings by default. If JSONValues is true, the value column keeps JSON encoded
+translations, so it can keep pluralisations and select forms too:
+
+	INSERT INTO translations VALUES ('en', '%n files', '[[null, 1, "%n file"], [2, null, "%n files"]]');
+
+Contexts and namespaces are not supported. Use other sources for dictionaries that have them.
+Table and column names are embedded into SQL as is, so they should not come from user input.
+*/
+type SQLSource struct {
+	DB *sql.DB
+	// Table is a name of the table. Default is "translations".
+	Table string
+	// LocaleColumn, KeyColumn and ValueColumn are names of columns. Defaults are "locale", "name" and "translation".
+	LocaleColumn string
+	KeyColumn    string
+	ValueColumn  string
+	// Placeholder is a bind parameter in SQL. Default is "?". Use "$1" for PostgreSQL.
+	Placeholder string
+	// JSONValues means values are JSON encoded translations (string, pluralisation array or select form).
+	JSONValues bool
+
+	lock sync.Mutex
+	// locales keeps locale values in the table that are found by Locales.
+	locales map[language.Tag]string
+}
+
+// NewSQLSource returns SQLSource that reads the table that has default column names.
+func NewSQLSource(db *sql.DB, table string) *SQLSource {
+	return &SQLSource{
+		DB:    db,
+		Table: table,
+	}
+}
+
+func (s *SQLSource) name(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func (s *SQLSource) Locales() ([]language.Tag, error) {
+	query := fmt.Sprintf("SELECT DISTINCT %s FROM %s",
+		s.name(s.LocaleColumn, "locale"), s.name(s.Table, "translations"))
+	rows, err := s.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []language.Tag
+	for rows.Next() {
+		var locale string
+		if err := rows.Scan(&locale); err != nil {
+			return nil, err
+		}
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid locale '%s'", locale)
+		}
+		s.lock.Lock()
+		if s.locales == nil {
+			s.locales = make(map[language.Tag]string)
+		}
+		s.locales[tag] = locale
+		s.lock.Unlock()
+		result = append(result, tag)
+	}
+	return result, rows.Err()
+}
+
+func (s *SQLSource) Load(tag language.Tag) (io.Reader, error) {
+	query := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s = %s",
+		s.name(s.KeyColumn, "name"), s.name(s.ValueColumn, "translation"), s.name(s.Table, "translations"),
+		s.name(s.LocaleColumn, "locale"), s.name(s.Placeholder, "?"))
+	s.lock.Lock()
+	locale, ok := s.locales[tag]
+	s.lock.Unlock()
+	if !ok {
+		locale = tag.String()
+	}
+	rows, err := s.DB.Query(query, locale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	values := make(map[string]interface{})
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		if !s.JSONValues {
+			values[key] = value
+		} else if json.Valid([]byte(value)) {
+			values[key] = json.RawMessage(value)
+		} else {
+			return nil, errors.Errorf("value of key '%s' in '%s' is not JSON", key, locale)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(map[string]interface{}{"values": values})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
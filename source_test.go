@@ -0,0 +1,191 @@
+package i18n4v
+
+import (
+	"context"
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/text/language"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFSSource(t *testing.T) {
+	Reset()
+	defer Reset()
+	fsys := fstest.MapFS{
+		"locales/ja.json":    {Data: []byte(`{"values": {"Hello": "こんにちは"}}`)},
+		"locales/pt-BR.json": {Data: []byte(`{"values": {"Hello": "Olá"}}`)},
+		"locales/README.md":  {Data: []byte(`# dictionaries`)},
+	}
+	if err := AddSource(FSSource(fsys, "locales")); err != nil {
+		t.Fatal(err)
+	}
+	if langs := Languages(); len(langs) != 2 {
+		t.Errorf("It should register locales in the source, but %v", langs)
+	}
+	if result := Select("pt-BR")("Hello"); result != "Olá" {
+		t.Errorf("It should load dictionary from fs, but '%s'", result)
+	}
+}
+
+func TestMapSource(t *testing.T) {
+	Reset()
+	defer Reset()
+	err := AddSource(MapSource{
+		language.Japanese: `{"values": {"Hello": "こんにちは"}}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := Select("ja")("Hello"); result != "こんにちは" {
+		t.Errorf("It should load dictionary from map, but '%s'", result)
+	}
+	if err := WatchSource(context.Background(), MapSource{}); err == nil {
+		t.Error("It should return error for source that can't be watched")
+	}
+}
+
+func TestDirSource(t *testing.T) {
+	Reset()
+	defer Reset()
+	dir, err := ioutil.TempDir("", "i18n4v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string, modified time.Time) {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, modified, modified)
+	}
+	base := time.Now().Add(-time.Hour)
+	write("ja.json", `{"values": {"Hello": "こんにちは"}}`, base)
+
+	source := DirSource(dir, 10*time.Millisecond)
+	if err := AddSource(source); err != nil {
+		t.Fatal(err)
+	}
+	if result := Select("ja")("Hello"); result != "こんにちは" {
+		t.Errorf("It should load dictionary from directory, but '%s'", result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- WatchSource(ctx, source)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	write("ja.json", `{"values": {"Hello": "やあ"}}`, base.Add(time.Minute))
+	write("fr.json", `{"values": {"Hello": "Bonjour"}}`, base)
+	time.Sleep(100 * time.Millisecond)
+
+	if result := Select("ja")("Hello"); result != "やあ" {
+		t.Errorf("Modified dictionary should be reloaded, but '%s'", result)
+	}
+	if result := Select("fr")("Hello"); result != "Bonjour" {
+		t.Errorf("Added dictionary should be registered, but '%s'", result)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "fr.json")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	if result := Select("fr")("Hello"); result == "Bonjour" {
+		t.Error("Removed dictionary should be unregistered")
+	}
+	if result := Select("ja")("Hello"); result != "やあ" {
+		t.Errorf("Other dictionaries should be kept, but '%s'", result)
+	}
+}
+
+func TestSQLSource(t *testing.T) {
+	Reset()
+	defer Reset()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// in-memory database is created for each connection
+	db.SetMaxOpenConns(1)
+	statements := []string{
+		`CREATE TABLE messages (lang TEXT, msgid TEXT, msgstr TEXT)`,
+		`INSERT INTO messages VALUES ('ja', 'Hello', 'こんにちは')`,
+		`INSERT INTO messages VALUES ('ja', 'Cancel', 'キャンセル')`,
+		`INSERT INTO messages VALUES ('en_US', 'Hello', 'Howdy')`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	source := &SQLSource{
+		DB:           db,
+		Table:        "messages",
+		LocaleColumn: "lang",
+		KeyColumn:    "msgid",
+		ValueColumn:  "msgstr",
+	}
+	if err := AddSource(source); err != nil {
+		t.Fatal(err)
+	}
+	if result := Select("ja")("Cancel"); result != "キャンセル" {
+		t.Errorf("It should load dictionary from database, but '%s'", result)
+	}
+	if result := Select("en-US")("Hello"); result != "Howdy" {
+		t.Errorf("It should use locale value in the table, but '%s'", result)
+	}
+
+	if _, err := NewSQLSource(db, "missing").Locales(); err == nil {
+		t.Error("It should return error for missing table")
+	}
+}
+
+func TestSQLSourceJSONValues(t *testing.T) {
+	Reset()
+	defer Reset()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	statements := []string{
+		`CREATE TABLE translations (locale TEXT, name TEXT, translation TEXT)`,
+		`INSERT INTO translations VALUES ('ja', 'Hello', '"こんにちは"')`,
+		`INSERT INTO translations VALUES ('ja', '%n files', '[[null, null, "%n 個のファイル"]]')`,
+		`INSERT INTO translations VALUES ('de', '%{name} replied', '{"select": "gender", "cases": {"female": "%{name} hat ihr geantwortet", "other": "%{name} hat geantwortet"}}')`,
+		`INSERT INTO translations VALUES ('fr', 'Hello', 'Bonjour')`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	source := NewSQLSource(db, "translations")
+	source.JSONValues = true
+	if err := AddSource(source); err != nil {
+		t.Fatal(err)
+	}
+	if result := Select("ja")("Hello"); result != "こんにちは" {
+		t.Errorf("It should load JSON string, but '%s'", result)
+	}
+	if result := Select("ja")("%n files", 3); result != "3 個のファイル" {
+		t.Errorf("It should load pluralisation, but '%s'", result)
+	}
+	if result := Select("de")("%{name} replied", Replace{"name": "Anna", "gender": "female"}); result != "Anna hat ihr geantwortet" {
+		t.Errorf("It should load select form, but '%s'", result)
+	}
+	if _, err := source.Load(language.French); err == nil {
+		t.Error("It should return error for value that is not JSON")
+	}
+}
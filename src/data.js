@@ -90,6 +90,8 @@ class Word {
     constructor(word, translation) {
         this._word = word;
         this.pluralisations = [];
+        // select form is kept as is
+        this.select = null;
         if (typeof(translation) === 'string') {
             this.pluralisations.push({min: null, max: null, word: translation});
         } else if (Array.isArray(translation)) {
@@ -100,9 +102,15 @@ class Word {
                     word: pluralisation[2]
                 });
             });
+        } else if (translation && typeof(translation.select) === 'string') {
+            this.select = translation;
         }
     }
 
+    isSelectForm() {
+        return this.select !== null;
+    }
+
     hasPluralisation() {
         return this.pluralisations.length > 1;
     }
@@ -119,6 +127,9 @@ class Word {
     }
 
     toJSON() {
+        if (this.select) {
+            return this.select;
+        }
         if (this.pluralisations.length === 0) {
             return undefined;
         } else if (this.pluralisations.length === 1) {
@@ -0,0 +1,154 @@
+package i18n4v
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// isHTMLKey checks whether the translation of key is HTML.
+// Keys that have "_html" suffix or that are listed in "html" of JSON are HTML.
+func (t *Translator) isHTMLKey(key string) bool {
+	if strings.HasSuffix(key, "_html") {
+		return true
+	}
+	t = t.source()
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.htmlKeys[key]
+}
+
+// escapeReplace returns arguments whose replacement values are escaped for HTML.
+// template.HTML values are treated as safe.
+func escapeReplace(args []interface{}) []interface{} {
+	result := make([]interface{}, len(args))
+	for i, arg := range args {
+		replace, ok := arg.(Replace)
+		if !ok {
+			result[i] = arg
+			continue
+		}
+		escaped := make(Replace, len(replace))
+		for key, value := range replace {
+			if html, ok := value.(template.HTML); ok {
+				escaped[key] = string(html)
+			} else {
+				escaped[key] = template.HTMLEscapeString(fmt.Sprintf("%v", value))
+			}
+		}
+		result[i] = escaped
+	}
+	return result
+}
+
+/*
+TranslateHTML method returns translated text as HTML.
+
+If the key is HTML (it has "_html" suffix or it is listed in "html" of JSON),
+markup in translation is kept and replacement values are escaped.
+Otherwise whole result is escaped. It escapes &'"<> like applyToHTML of JavaScript runtime.
+
+Parameters are same as Translate.
+*/
+func (t *Translator) TranslateHTML(text string, args ...interface{}) template.HTML {
+	if t.isHTMLKey(text) {
+		return template.HTML(t.Translate(text, escapeReplace(args)...))
+	}
+	return template.HTML(template.HTMLEscapeString(t.Translate(text, args...)))
+}
+
+func (t *Translator) translateForTemplate(text string, args ...interface{}) interface{} {
+	if t.isHTMLKey(text) {
+		return t.TranslateHTML(text, args...)
+	}
+	// html/template escapes it for its context (text, attribute, URL and so on)
+	return t.Translate(text, args...)
+}
+
+func pairs(name string, args []interface{}) (map[string]interface{}, error) {
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("%s needs key and value pairs", name)
+	}
+	result := make(map[string]interface{}, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("key of %s should be string, but '%v'", name, args[i])
+		}
+		result[key] = args[i+1]
+	}
+	return result, nil
+}
+
+/*
+FuncMap returns functions for html/template that use the translator.
+
+It provides the following functions:
+
+    t:       translates text. It accepts the same parameters as Translate
+    tn:      translates text with count. It is a shortcut of t with count
+    replace: creates Replace from key and value pairs
+    context: creates Context from key and value pairs
+
+Sample:
+
+    {{t "Welcome %{name}" (replace "name" .User.Name)}}
+    {{tn "%n comments" .Count}}
+
+Template functions should be registered before parsing. To use different translator per request,
+parse templates with FuncMap(nil) and call Funcs with request's translator on cloned template:
+
+    tmpl := template.Must(template.New("").Funcs(i18n4v.FuncMap(nil)).Parse(src))
+
+    func handler(w http.ResponseWriter, r *http.Request) {
+        t := template.Must(tmpl.Clone()).Funcs(i18n4v.FuncMapWithRequest(r))
+        t.Execute(w, data)
+    }
+*/
+func FuncMap(t *Translator) template.FuncMap {
+	return template.FuncMap(funcMap(t, true))
+}
+
+// funcMap returns template functions. If html is false, it returns functions for text/template.
+func funcMap(t *Translator, html bool) map[string]interface{} {
+	if t == nil {
+		t = defaultTranslator
+	}
+	translate := func(text string, args ...interface{}) interface{} {
+		return t.Translate(text, args...)
+	}
+	if html {
+		translate = t.translateForTemplate
+	}
+	return map[string]interface{}{
+		"t": func(text string, args ...interface{}) interface{} {
+			return translate(text, args...)
+		},
+		"tn": func(text string, count int, args ...interface{}) interface{} {
+			return translate(text, append([]interface{}{count}, args...)...)
+		},
+		"replace": func(args ...interface{}) (Replace, error) {
+			return pairs("replace", args)
+		},
+		"context": func(args ...interface{}) (Context, error) {
+			values, err := pairs("context", args)
+			if err != nil {
+				return nil, err
+			}
+			result := make(Context, len(values))
+			for key, value := range values {
+				result[key] = fmt.Sprintf("%v", value)
+			}
+			return result, nil
+		},
+	}
+}
+
+/*
+FuncMapWithRequest returns functions for html/template that use the translator
+selected by Accept-Language header of the request.
+*/
+func FuncMapWithRequest(r *http.Request) template.FuncMap {
+	return FuncMap(SelectTranslatorWithRequest(r))
+}
@@ -0,0 +1,50 @@
+package i18n4v
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+)
+
+func TestFuncMap(t *testing.T) {
+	ja := MustCreateFromString(`{
+        "values": {
+            "Welcome %{name}": "ようこそ %{name} さん",
+            "Read <a href=\"/terms\">terms</a> of %{service}": "%{service}の<a href=\"/terms\">利用規約</a>を読んでください",
+            "notice_html": "<b>%{name}</b>さん、お知らせがあります",
+            "%n comments": [
+                [0, null, "%n 件のコメント"]
+            ]
+        },
+        "html": ["Read <a href=\"/terms\">terms</a> of %{service}"]
+    }`)
+	tmpl := template.Must(template.New("test").Funcs(FuncMap(nil)).Parse(
+		`<p>{{t "Welcome %{name}" (replace "name" .Name)}}</p>` +
+			`<p>{{t "Read <a href=\"/terms\">terms</a> of %{service}" (replace "service" .Name)}}</p>` +
+			`<p>{{t "notice_html" (replace "name" .Name)}}</p>` +
+			`<p title="{{tn "%n comments" 3}}">{{tn "%n comments" 3}}</p>`))
+	tmpl = template.Must(tmpl.Clone()).Funcs(FuncMap(ja))
+
+	var buffer bytes.Buffer
+	err := tmpl.Execute(&buffer, map[string]string{"Name": "<script>"})
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	expected := `<p>ようこそ &lt;script&gt; さん</p>` +
+		`<p>&lt;script&gt;の<a href="/terms">利用規約</a>を読んでください</p>` +
+		`<p><b>&lt;script&gt;</b>さん、お知らせがあります</p>` +
+		`<p title="3 件のコメント">3 件のコメント</p>`
+	if buffer.String() != expected {
+		t.Errorf("It should escape replacement values, but\n%s\n!=\n%s", buffer.String(), expected)
+	}
+}
+
+func TestTranslateHTML(t *testing.T) {
+	en := MustCreateFromString(`{}`)
+	if r := en.TranslateHTML("Tom & Jerry"); r != "Tom &amp; Jerry" {
+		t.Errorf("It should escape text of non HTML key, but %s", r)
+	}
+	if r := en.TranslateHTML("<b>%{name}</b>_html", Replace{"name": template.HTML("<i>Tom</i>")}); r != "<b><i>Tom</i></b>_html" {
+		t.Errorf("It should keep template.HTML values, but %s", r)
+	}
+}
@@ -40,6 +40,20 @@ describe('parse/save JSON', () => {
         assert(words.values()[0].pluralisations.length === 3);
     });
 
+    it('keeps select forms', () => {
+        const words = data.loadJSON(`{
+            "values": {
+                "%{name} replied": {
+                    "select": "gender",
+                    "cases": {"female": "%{name} hat ihr geantwortet", "other": "%{name} hat geantwortet"}
+                }
+            }
+        }`);
+        assert(words.value("%{name} replied").isSelectForm());
+        const loadedWords = data.loadJSON(words.toJSON());
+        assert(loadedWords.value("%{name} replied").select.cases.female === "%{name} hat ihr geantwortet");
+    });
+
     it('can read from local file', () => {
         return data.loadJSONFile(resolve(__dirname, 'sample.json')).then(words => {
             assert(words.valueKeys().length === 2);
@@ -111,6 +111,115 @@ describe('en translator with full feature', function () {
     });
 });
 
+describe('select forms', function () {
+    var de = i18n.create({
+        values: {
+            "%{name} updated their profile": {
+                "select": "gender",
+                "cases": {
+                    "male": "%{name} hat sein Profil aktualisiert",
+                    "female": "%{name} hat ihr Profil aktualisiert",
+                    "other": "%{name} hat das Profil aktualisiert"
+                }
+            },
+            "%n new messages for %{name}": {
+                "select": "gender",
+                "cases": {
+                    "female": [
+                        [0, 1, "%n neue Nachricht für sie"],
+                        [2, null, "%n neue Nachrichten für sie"]
+                    ],
+                    "other": [
+                        [0, 1, "%n neue Nachricht"],
+                        [2, null, "%n neue Nachrichten"]
+                    ]
+                },
+                "fuzzy": ["female"]
+            }
+        }
+    });
+
+    it('can select case by context', function () {
+        assert(de("%{name} updated their profile", { name: "Anna" }, { gender: "female" }) === "Anna hat ihr Profil aktualisiert");
+    });
+
+    it('can select case by replacement', function () {
+        assert(de("%{name} updated their profile", { name: "Max", gender: "male" }) === "Max hat sein Profil aktualisiert");
+    });
+
+    it('uses other case if no case matches', function () {
+        assert(de("%{name} updated their profile", { name: "Kim" }) === "Kim hat das Profil aktualisiert");
+    });
+
+    it('can select pluralisation and skips fuzzy cases', function () {
+        assert(de("%n new messages for %{name}", 2, { name: "Anna" }, { gender: "female" }) === "2 neue Nachrichten");
+    });
+});
+
+describe('fuzzy translations', function () {
+    var ja = i18n.create({
+        values: {
+            "Save": "保存",
+            "Draft": "下書き"
+        },
+        contexts: [
+            {
+                "matches": {"gender": "female"},
+                "values": {
+                    "Save": "保存する",
+                    "Draft": "彼女の下書き"
+                },
+                "fuzzy": ["Save"]
+            }
+        ],
+        fuzzy: ["Draft"]
+    });
+
+    it('does not use fuzzy root values', function () {
+        assert(ja("Save") === "保存");
+        assert(ja("Draft") === "Draft");
+    });
+
+    it('does not use fuzzy values in contexts', function () {
+        assert(ja("Save", {}, { gender: "female" }) === "保存");
+        assert(ja("Draft", {}, { gender: "female" }) === "彼女の下書き");
+    });
+});
+
+describe('context specificity', function () {
+    var ja = i18n.create({
+        values: {
+            "Welcome": "ようこそ",
+            "Logout": "ログアウト"
+        },
+        contexts: [
+            {
+                "matches": {"gender": "female"},
+                "values": {
+                    "Welcome": "ようこそ(女性)",
+                    "Logout": "ログアウト(女性)"
+                }
+            },
+            {
+                "matches": {"gender": "female", "age": "child"},
+                "values": {
+                    "Welcome": "ようこそ(女の子)"
+                }
+            }
+        ]
+    });
+
+    it('uses the most specific context first like Go runtime', function () {
+        assert(ja("Welcome", {}, { gender: "female", age: "child" }) === "ようこそ(女の子)");
+        assert(ja("Welcome", {}, { gender: "female" }) === "ようこそ(女性)");
+    });
+
+    it('falls back to less specific contexts', function () {
+        assert(ja("Logout", {}, { gender: "female", age: "child" }) === "ログアウト(女性)");
+        assert(ja("Logout", {}, { gender: "male", age: "child" }) === "ログアウト");
+    });
+});
+
 describe('default i18n', function () {
     before(function () {
         i18n.translator.add({
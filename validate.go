@@ -0,0 +1,337 @@
+package i18n4v
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+Problem describes an issue in a dictionary that is found by Validate or Compare.
+
+File is not set by Validate and Compare. Tools that read files can fill it.
+Line and Column are zero if the problem doesn't have position (like missing keys).
+*/
+type Problem struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+	Context string `json:"context,omitempty"`
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p *Problem) String() string {
+	switch {
+	case p.Line == 0 && p.File == "":
+		return p.Message
+	case p.Line == 0:
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	case p.File == "":
+		return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Column, p.Message)
+}
+
+var placeholderPattern = regexp.MustCompile(`%\{([^}]+)\}`)
+
+func placeholders(text string) []string {
+	var result []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+		result = append(result, match[1])
+	}
+	return result
+}
+
+type validator struct {
+	src      *jsonSource
+	problems []*Problem
+}
+
+func (v *validator) report(offset int, context, key, format string, args ...interface{}) {
+	line, column := v.src.position(offset)
+	v.problems = append(v.problems, &Problem{
+		Line:    line,
+		Column:  column,
+		Context: context,
+		Key:     key,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+/*
+Validate reads dictionary JSON and reports problems in it.
+
+It detects the following problems that Create and Add accept silently:
+
+* Overlapped or gapped pluralisation ranges
+* Pluralisation entries that don't have three elements
+* Placeholders (%{key}) in translation that are missing in key
+* Contexts that have empty matches
+* Duplicated keys
+* Select forms that don't have "other" case
+
+If JSON format is invalid, it returns error.
+*/
+func Validate(reader io.Reader) ([]*Problem, error) {
+	src, root, err := parseJSONNode(reader)
+	if err != nil {
+		return nil, err
+	}
+	v := &validator{src: src}
+	v.validate(root)
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		if v.problems[i].Line != v.problems[j].Line {
+			return v.problems[i].Line < v.problems[j].Line
+		}
+		return v.problems[i].Column < v.problems[j].Column
+	})
+	return v.problems, nil
+}
+
+func (v *validator) validate(root *jsonNode) {
+	v.validateDictionary(root, "")
+	if !root.isObj {
+		return
+	}
+	namespaces := root.member("namespaces")
+	if namespaces == nil {
+		return
+	}
+	if !namespaces.value.isObj {
+		v.report(namespaces.value.offset, "", "", "namespaces should be object")
+		return
+	}
+	v.checkDuplication(namespaces.value, "", "namespaces")
+	for _, namespace := range namespaces.value.members() {
+		prefix := namespacePrefix(namespace.key)
+		v.validateDictionary(namespace.value, prefix)
+		if namespace.value.member("namespaces") != nil {
+			v.report(namespace.value.offset, strings.TrimSpace(prefix), "", "namespaces can't be nested")
+		}
+	}
+}
+
+// validateDictionary checks values and contexts. prefix is added to context names of problems.
+func (v *validator) validateDictionary(root *jsonNode, prefix string) {
+	if !root.isObj {
+		v.report(root.offset, strings.TrimSpace(prefix), "", "dictionary should be object")
+		return
+	}
+	v.checkDuplication(root, strings.TrimSpace(prefix), "")
+	if values := root.member("values"); values != nil {
+		v.validateValues(prefix+"root values", values.value)
+	}
+	contexts := root.member("contexts")
+	if contexts == nil {
+		return
+	}
+	if !contexts.value.isArray {
+		v.report(contexts.value.offset, strings.TrimSpace(prefix), "", "contexts should be array")
+		return
+	}
+	for i, contextNode := range contexts.value.array() {
+		contextName := fmt.Sprintf("%scontext[%d]", prefix, i)
+		if !contextNode.isObj {
+			v.report(contextNode.offset, contextName, "", "%s should be object", contextName)
+			continue
+		}
+		v.checkDuplication(contextNode, contextName, "")
+		matches := contextNode.member("matches")
+		if matches == nil || !matches.value.isObj || len(matches.value.members()) == 0 {
+			v.report(contextNode.offset, contextName, "", "%s has empty matches", contextName)
+		} else {
+			v.checkDuplication(matches.value, contextName, "matches")
+			for _, match := range matches.value.members() {
+				if _, ok := match.value.value.(string); !ok {
+					v.report(match.value.offset, contextName, "", "match '%s' at %s should be string", match.key, contextName)
+				}
+			}
+		}
+		if values := contextNode.member("values"); values != nil {
+			v.validateValues(contextName, values.value)
+		}
+	}
+}
+
+func (v *validator) checkDuplication(object *jsonNode, context, name string) {
+	found := make(map[string]bool)
+	for _, member := range object.members() {
+		if found[member.key] {
+			if name == "" {
+				v.report(member.keyOffset, context, member.key, "key '%s' is duplicated", member.key)
+			} else {
+				v.report(member.keyOffset, context, member.key, "key '%s' in %s at %s is duplicated", member.key, name, context)
+			}
+		}
+		found[member.key] = true
+	}
+}
+
+func (v *validator) validateValues(context string, values *jsonNode) {
+	if !values.isObj {
+		v.report(values.offset, context, "", "values at %s should be object", context)
+		return
+	}
+	v.checkDuplication(values, context, "values")
+	for _, member := range values.members() {
+		v.validateValue(context, member.key, member.value)
+	}
+}
+
+type pluralisationRange struct {
+	min    int64
+	max    int64
+	offset int
+}
+
+func (v *validator) validateValue(context, key string, value *jsonNode) {
+	if text, ok := value.value.(string); ok {
+		v.checkPlaceholders(context, key, text, value.offset)
+		return
+	}
+	if value.isObj {
+		v.validateSelect(context, key, value)
+		return
+	}
+	if !value.isArray {
+		v.report(value.offset, context, key, "value of key '%s' at %s should be string, pluralisation array or select form, but '%v'", key, context, value.value)
+		return
+	}
+	var ranges []*pluralisationRange
+	for _, pluralisation := range value.array() {
+		spec := pluralisation.array()
+		if len(spec) != 3 {
+			v.report(pluralisation.offset, context, key, "pluralisation of key '%s' at %s should have 3 elements, but %d", key, context, len(spec))
+			continue
+		}
+		min, ok := nodeNumber(spec[0], math.MinInt64)
+		if !ok {
+			v.report(spec[0].offset, context, key, "First value of key '%s' at %s should be int, but '%v'", key, context, spec[0].value)
+			continue
+		}
+		max, ok := nodeNumber(spec[1], math.MaxInt64)
+		if !ok {
+			v.report(spec[1].offset, context, key, "Second value of key '%s' at %s should be int, but '%v'", key, context, spec[1].value)
+			continue
+		}
+		text, ok := spec[2].value.(string)
+		if !ok {
+			v.report(spec[2].offset, context, key, "Third value of key '%s' at %s should be string, but '%v'", key, context, spec[2].value)
+			continue
+		}
+		v.checkPlaceholders(context, key, text, spec[2].offset)
+		if min > max {
+			v.report(pluralisation.offset, context, key, "pluralisation range of key '%s' at %s is empty: [%s, %s]", key, context, formatBound(min), formatBound(max))
+			continue
+		}
+		ranges = append(ranges, &pluralisationRange{min: min, max: max, offset: pluralisation.offset})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].min < ranges[j].min
+	})
+	if len(ranges) == 0 {
+		return
+	}
+	// prev is the range that reaches the farthest among previous ranges
+	prev := ranges[0]
+	for _, current := range ranges[1:] {
+		if current.min <= prev.max {
+			v.report(current.offset, context, key, "pluralisation ranges of key '%s' at %s overlap: [%s, %s] and [%s, %s]", key, context,
+				formatBound(prev.min), formatBound(prev.max), formatBound(current.min), formatBound(current.max))
+		} else if current.min-1 > prev.max {
+			v.report(current.offset, context, key, "pluralisation ranges of key '%s' at %s have gap: from %d to %d", key, context,
+				prev.max+1, current.min-1)
+		}
+		if current.max > prev.max {
+			prev = current
+		}
+	}
+}
+
+// validateSelect validates select form like {"select": "gender", "cases": {...}} and its cases.
+func (v *validator) validateSelect(context, key string, value *jsonNode) {
+	v.checkDuplication(value, context, "select form of key '"+key+"'")
+	selector := value.member("select")
+	if selector == nil {
+		v.report(value.offset, context, key, "select form of key '%s' at %s should have \"select\"", key, context)
+		return
+	}
+	name, ok := selector.value.value.(string)
+	if !ok || name == "" {
+		v.report(selector.value.offset, context, key, "select of key '%s' at %s should be selector name, but '%v'", key, context, selector.value.value)
+		return
+	}
+	cases := value.member("cases")
+	if cases == nil {
+		v.report(value.offset, context, key, "select form of key '%s' at %s should have \"cases\"", key, context)
+		return
+	}
+	if !cases.value.isObj || len(cases.value.members()) == 0 {
+		v.report(cases.value.offset, context, key, "cases of key '%s' at %s should be non-empty object", key, context)
+		return
+	}
+	v.checkDuplication(cases.value, context, "cases of key '"+key+"'")
+	for _, member := range value.members() {
+		switch member.key {
+		case "select", "cases":
+		case "fuzzy":
+			for _, item := range member.value.array() {
+				if caseName, ok := item.value.(string); !ok || cases.value.member(caseName) == nil {
+					v.report(item.offset, context, key, "fuzzy of key '%s' at %s should have case names, but '%v'", key, context, item.value)
+				}
+			}
+		default:
+			v.report(member.keyOffset, context, key, "select form of key '%s' at %s has unknown member '%s'", key, context, member.key)
+		}
+	}
+	if cases.value.member(otherCase) == nil {
+		v.report(cases.value.offset, context, key, "cases of key '%s' at %s should have \"other\" for unknown %s", key, context, name)
+	}
+	for _, member := range cases.value.members() {
+		v.validateValue(fmt.Sprintf("%s (%s=%s)", context, name, member.key), key, member.value)
+	}
+}
+
+func (v *validator) checkPlaceholders(context, key, text string, offset int) {
+	keyPlaceholders := make(map[string]bool)
+	for _, name := range placeholders(key) {
+		keyPlaceholders[name] = true
+	}
+	for _, name := range placeholders(text) {
+		if !keyPlaceholders[name] {
+			v.report(offset, context, key, "placeholder '%%{%s}' of key '%s' at %s is missing in key", name, key, context)
+		}
+	}
+}
+
+func nodeNumber(node *jsonNode, defaultValue int64) (int64, bool) {
+	if node.value == nil && !node.isArray && !node.isObj {
+		return defaultValue, true
+	}
+	number, ok := node.value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	if value, err := number.Int64(); err == nil {
+		return value, true
+	}
+	value, err := number.Float64()
+	if err != nil {
+		return 0, false
+	}
+	return int64(value), true
+}
+
+func formatBound(value int64) string {
+	if value == math.MinInt64 || value == math.MaxInt64 {
+		return "null"
+	}
+	return strconv.FormatInt(value, 10)
+}
@@ -0,0 +1,155 @@
+package i18n4v
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	problems, err := Validate(strings.NewReader(`{
+    "values": {
+        "Cancel": "Cancelar",
+        "%n comments": [
+            [0, 0, "%n comments"],
+            [1, 2, "%n comment"],
+            [2, null, "%n comments"]
+        ],
+        "Due in %n days": [
+            [null, -2, "Due -%n days ago"],
+            [0, 0, "Due Today"],
+            [1]
+        ],
+        "Welcome": "Welcome %{name}",
+        "Cancel": "Annuler"
+    },
+    "contexts": [
+        {
+            "matches": {},
+            "values": {}
+        }
+    ]
+}`))
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	expected := []struct {
+		line    int
+		message string
+	}{
+		{7, "overlap"},
+		{12, "should have 3 elements"},
+		{11, "have gap: from -1 to -1"},
+		{14, "placeholder '%{name}'"},
+		{15, "key 'Cancel' in values at root values is duplicated"},
+		{18, "context[0] has empty matches"},
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("It should find %d problems, but %d: %v", len(expected), len(problems), problems)
+	}
+	for _, e := range expected {
+		found := false
+		for _, problem := range problems {
+			if problem.Line == e.line && strings.Contains(problem.Message, e.message) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("It should report '%s' at line %d, but %v", e.message, e.line, problems)
+		}
+	}
+}
+
+func TestValidateNestedRanges(t *testing.T) {
+	problems, err := Validate(strings.NewReader(`{
+    "values": {
+        "%n files": [[0, 10, "%n files"], [1, 1, "%n file"], [5, 5, "%n files"], [11, null, "%n files"]]
+    }
+}`))
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	if len(problems) != 2 {
+		t.Fatalf("It should find 2 overlaps, but %v", problems)
+	}
+	if !strings.Contains(problems[0].Message, "overlap: [0, 10] and [1, 1]") || !strings.Contains(problems[1].Message, "overlap: [0, 10] and [5, 5]") {
+		t.Errorf("Ranges should be compared with the widest previous range, but %v", problems)
+	}
+}
+
+func TestValidateSyntaxError(t *testing.T) {
+	_, err := Validate(strings.NewReader(`{
+    "values": {
+        "Cancel": "Cancelar",
+    }
+}`))
+	if err == nil {
+		t.Errorf("It should return error for invalid JSON")
+	}
+}
+
+func TestValidateNamespaces(t *testing.T) {
+	problems, err := Validate(strings.NewReader(`{
+    "values": {"Save": "Guardar"},
+    "namespaces": {
+        "billing": {
+            "values": {"Pay %{amount}": "Pagar %{total}"},
+            "namespaces": {}
+        }
+    }
+}`))
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	if len(problems) != 2 {
+		t.Fatalf("It should report 2 problems, but %v", problems)
+	}
+	if problems[0].Context != "namespace[billing]" || problems[0].Line != 4 {
+		t.Errorf("It should report nested namespaces, but %v", problems[0])
+	}
+	if problems[1].Context != "namespace[billing] root values" || problems[1].Key != "Pay %{amount}" {
+		t.Errorf("It should report placeholder of the namespace, but %v", problems[1])
+	}
+}
+
+func TestValidateSelect(t *testing.T) {
+	problems, err := Validate(strings.NewReader(`{
+    "values": {
+        "Welcome": {
+            "select": "gender",
+            "cases": {
+                "female": "Welcome %{name}",
+                "other": [[1, 1, "Welcome"], [3, null, "Welcome all"]]
+            }
+        },
+        "Hello": {"select": "gender", "cases": {"male": "Hello sir"}, "fuzzy": ["female"]},
+        "Goodbye": {"cases": {"other": "Goodbye"}}
+    }
+}`))
+	if err != nil {
+		t.Fatalf("It should not return error: %v", err)
+	}
+	expected := []struct {
+		line    int
+		message string
+	}{
+		{6, "placeholder '%{name}' of key 'Welcome' at root values (gender=female)"},
+		{7, "have gap: from 2 to 2"},
+		{10, "should have \"other\""},
+		{10, "fuzzy of key 'Hello' at root values should have case names, but 'female'"},
+		{11, "should have \"select\""},
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("It should find %d problems, but %d: %v", len(expected), len(problems), problems)
+	}
+	for _, e := range expected {
+		found := false
+		for _, problem := range problems {
+			if problem.Line == e.line && strings.Contains(problem.Message, e.message) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("It should report '%s' at line %d, but %v", e.message, e.line, problems)
+		}
+	}
+}